@@ -0,0 +1,439 @@
+package main
+
+import (
+	"ast-callgraph/render"
+	"ast-callgraph/schema"
+	"ast-callgraph/server"
+	"ast-callgraph/service"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	formatText = "text"
+	formatJson = "json"
+)
+
+type commandFunc func(ctx context.Context, args []string) error
+
+var commands = map[string]commandFunc{
+	"structs":    runStructs,
+	"interfaces": runInterfaces,
+	"callgraph":  runCallGraph,
+	"deps":       runDeps,
+	"export":     runExport,
+	"graph":      runGraph,
+	"serve":      runServe,
+	"query":      runQuery,
+	"deadcode":   runDeadCode,
+	"impact":     runImpact,
+	"platforms":  runPlatforms,
+	"tests":      runTests,
+}
+
+// patternsFlag 可重复或逗号分隔的匹配模式
+type patternsFlag []string
+
+func (p *patternsFlag) String() string {
+	return strings.Join(*p, ",")
+}
+
+func (p *patternsFlag) Set(value string) error {
+	for _, pattern := range strings.Split(value, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			*p = append(*p, pattern)
+		}
+	}
+	return nil
+}
+
+// commonFlags 各子命令共用的参数
+type commonFlags struct {
+	directory    string
+	goModPath    string
+	goWorkPath   string
+	replaces     bool
+	goos         string
+	goarch       string
+	tags         patternsFlag
+	includeTests bool
+	vendor       bool
+	skipGen      bool
+	includes     patternsFlag
+	excludes     patternsFlag
+	format       string
+	typeCheck    bool
+	workers      int
+	cacheDir     string
+	strict       bool
+}
+
+func newFlagSet(name string) (*flag.FlagSet, *commonFlags) {
+	flags := &commonFlags{}
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&flags.directory, "dir", ".", "module directory to analyze")
+	fs.StringVar(&flags.goModPath, "gomod", "", "path of go.mod, discovered upward from -dir when empty")
+	fs.StringVar(&flags.goWorkPath, "gowork", "", "path of go.work, taken from $GOWORK or discovered upward from -dir when empty, off disables workspace mode")
+	fs.StringVar(&flags.goos, "goos", "", "target GOOS for build constraints, $GOOS or the host when empty")
+	fs.StringVar(&flags.goarch, "goarch", "", "target GOARCH for build constraints, $GOARCH or the host when empty")
+	fs.Var(&flags.tags, "tags", "build tags considered satisfied (repeatable or comma separated)")
+	fs.BoolVar(&flags.includeTests, "tests", false, "also analyze _test.go files, external test packages get the pkg/path_test path")
+	fs.BoolVar(&flags.replaces, "follow-replace", false, "also analyze dependencies replaced by local directories in go.mod or go.work")
+	fs.BoolVar(&flags.vendor, "vendor", false, "also analyze vendor directories, skipped by default like testdata and dirs starting with . or _")
+	fs.BoolVar(&flags.skipGen, "skip-generated", false, "skip files marked with // Code generated ... DO NOT EDIT.")
+	fs.Var(&flags.includes, "include", "only analyze files matching the glob (repeatable or comma separated)")
+	fs.Var(&flags.excludes, "exclude", "skip files and directories matching the glob (repeatable or comma separated)")
+	fs.StringVar(&flags.format, "format", formatText, "output format: text or json")
+	fs.IntVar(&flags.workers, "workers", 0, "number of files parsed concurrently, 0 means number of CPUs")
+	fs.StringVar(&flags.cacheDir, "cache", "", "directory caching per-file results keyed by content hash, ignored with -typecheck")
+	fs.BoolVar(&flags.typeCheck, "typecheck", false, "resolve calls with full type information, packages failing to type-check fall back to name based resolution")
+	fs.BoolVar(&flags.strict, "strict", false, "fail when any file cannot be read, parsed or type-checked instead of reporting diagnostics and analyzing the rest")
+	return fs, flags
+}
+
+func (c *commonFlags) parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments %v", fs.Args())
+	}
+	if c.format != formatText && c.format != formatJson {
+		return fmt.Errorf("unsupported format %q", c.format)
+	}
+	return nil
+}
+
+func (c *commonFlags) transverseParam() *service.AstTransverseParam {
+	param := &service.AstTransverseParam{
+		Directory:      c.directory,
+		GoWorkPath:     c.goWorkPath,
+		FollowReplaces: c.replaces,
+		GOOS:           c.goos,
+		GOARCH:         c.goarch,
+		BuildTags:      c.tags,
+		IncludeTests:   c.includeTests,
+		IncludeVendor:  c.vendor,
+		SkipGenerated:  c.skipGen,
+		Includes:       c.includes,
+		Excludes:       c.excludes,
+		TypeCheck:      c.typeCheck,
+		Workers:        c.workers,
+		CacheDir:       c.cacheDir,
+		Strict:         c.strict,
+	}
+	if c.goModPath != "" {
+		param.GoModPath = &c.goModPath
+	}
+	return param
+}
+
+func (c *commonFlags) transverse(ctx context.Context) (*service.AstTransverseInfo, error) {
+	info, err := service.TransverseDirectory(ctx, c.transverseParam())
+	if err != nil {
+		return nil, fmt.Errorf("analyze %s: %w", c.directory, err)
+	}
+	// 诊断输出到标准错误，不影响标准输出的文本或 JSON 结果
+	for _, diagnostic := range info.Diagnostics {
+		fmt.Fprintln(os.Stderr, diagnostic)
+	}
+	return info, nil
+}
+
+func runStructs(ctx context.Context, args []string) error {
+	fs, flags := newFlagSet("structs")
+	showFields := fs.Bool("fields", false, "also list fields with their types and struct tags in text output")
+	if err := flags.parse(fs, args); err != nil {
+		return err
+	}
+	info, err := flags.transverse(ctx)
+	if err != nil {
+		return err
+	}
+	if flags.format == formatJson {
+		// 与 export 一致经 schema 转换，位置信息为相对 rootDir 的 {file, line, column}
+		outputs := make([]*schema.Struct, 0)
+		for _, structInfos := range info.StructInfoMap {
+			for _, structInfo := range structInfos {
+				outputs = append(outputs, schema.FromStructInfo(structInfo))
+			}
+		}
+		sort.Slice(outputs, func(i, j int) bool {
+			return outputs[i].TypeName < outputs[j].TypeName
+		})
+		return writeJson(os.Stdout, outputs)
+	}
+	pkgs := make([]string, 0, len(info.StructInfoMap))
+	for pkg := range info.StructInfoMap {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+	for _, pkg := range pkgs {
+		for _, structInfo := range info.StructInfoMap[pkg] {
+			fmt.Printf("%s\t%s:%d-%d\n", structInfo.TypeName, structInfo.File, structInfo.StartLine, structInfo.EndLine)
+			if *showFields {
+				for _, field := range structInfo.Fields {
+					fmt.Printf("\tfield %s %s", field.Name, field.Type)
+					if field.Tag != "" {
+						fmt.Printf(" `%s`", field.Tag)
+					}
+					fmt.Printf("\t%s:%d\n", structInfo.File, field.Pos.Line)
+				}
+			}
+			deps := make([]string, 0)
+			for _, indexes := range structInfo.DepsStructInfo {
+				for _, index := range indexes {
+					if index.Embedded {
+						deps = append(deps, index.Pkg+"."+index.Name+" (embedded)")
+					} else {
+						deps = append(deps, index.Pkg+"."+index.Name)
+					}
+				}
+			}
+			sort.Strings(deps)
+			for _, dep := range deps {
+				fmt.Printf("\t-> %s\n", dep)
+			}
+			for _, method := range structInfo.Methods {
+				fmt.Printf("\tmethod %s\n", method.Key)
+			}
+			for _, method := range structInfo.PromotedMethods {
+				fmt.Printf("\tmethod %s.%s (promoted via %s)\n", method.Pkg, method.Key, strings.Join(method.Via, "."))
+			}
+		}
+	}
+	return nil
+}
+
+func runInterfaces(ctx context.Context, args []string) error {
+	fs, flags := newFlagSet("interfaces")
+	if err := flags.parse(fs, args); err != nil {
+		return err
+	}
+	info, err := flags.transverse(ctx)
+	if err != nil {
+		return err
+	}
+	outputs := make([]*schema.Interface, 0)
+	for _, interfaceInfos := range info.InterfaceInfoMap {
+		for _, interfaceInfo := range interfaceInfos {
+			outputs = append(outputs, schema.FromInterfaceInfo(interfaceInfo, info.Implements[interfaceInfo.TypeName]))
+		}
+	}
+	sort.Slice(outputs, func(i, j int) bool {
+		return outputs[i].TypeName < outputs[j].TypeName
+	})
+	if flags.format == formatJson {
+		return writeJson(os.Stdout, outputs)
+	}
+	for _, output := range outputs {
+		fmt.Printf("%s\t%s:%d-%d\n", output.TypeName, output.File, output.StartLine, output.EndLine)
+		for _, implementation := range output.Implementations {
+			pointer := ""
+			if implementation.Pointer {
+				pointer = "*"
+			}
+			fmt.Printf("\t<- %s%s.%s\n", pointer, implementation.Pkg, implementation.Name)
+		}
+	}
+	return nil
+}
+
+// callEdgeOutput 调用边的输出格式
+type callEdgeOutput struct {
+	Caller  string `json:"caller"`
+	Callee  string `json:"callee"`
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Dynamic bool   `json:"dynamic"`
+}
+
+func runCallGraph(ctx context.Context, args []string) error {
+	fs, flags := newFlagSet("callgraph")
+	if err := flags.parse(fs, args); err != nil {
+		return err
+	}
+	info, err := flags.transverse(ctx)
+	if err != nil {
+		return err
+	}
+	outputs := make([]*callEdgeOutput, 0, len(info.CallEdges))
+	for _, edge := range info.CallEdges {
+		outputs = append(outputs, &callEdgeOutput{
+			Caller:  edge.Caller.Pkg + "." + edge.Caller.Key(),
+			Callee:  edge.Callee.Pkg + "." + edge.Callee.Key(),
+			File:    edge.Caller.RFile,
+			Line:    edge.CallSite.Begin.Line,
+			Column:  edge.CallSite.Begin.Column,
+			Dynamic: edge.Dynamic,
+		})
+	}
+	if flags.format == formatJson {
+		return writeJson(os.Stdout, outputs)
+	}
+	for _, output := range outputs {
+		kind := ""
+		if output.Dynamic {
+			kind = " (dynamic)"
+		}
+		fmt.Printf("%s -> %s%s\t%s:%d:%d\n", output.Caller, output.Callee, kind, output.File, output.Line, output.Column)
+	}
+	return nil
+}
+
+func runDeps(ctx context.Context, args []string) error {
+	fs, flags := newFlagSet("deps")
+	if err := flags.parse(fs, args); err != nil {
+		return err
+	}
+	param := flags.transverseParam()
+	workspace, err := service.DiscoverModules(ctx, param)
+	if err != nil {
+		return fmt.Errorf("parse go.mod: %w", err)
+	}
+	if flags.format == formatJson {
+		return writeJson(os.Stdout, workspace)
+	}
+	if workspace.Work != nil {
+		fmt.Printf("work %s\n", workspace.Work.WorkPath)
+		printReplaces(workspace.Work.Replaces)
+	}
+	for _, modFileInfo := range workspace.Modules {
+		fmt.Printf("module %s\t%s\n", modFileInfo.RootPkg, modFileInfo.ModPath)
+		if modFileInfo.GoVersion != "" {
+			fmt.Printf("\tgo %s\n", modFileInfo.GoVersion)
+		}
+		if modFileInfo.Toolchain != "" {
+			fmt.Printf("\ttoolchain %s\n", modFileInfo.Toolchain)
+		}
+		for _, dep := range modFileInfo.DepsMods {
+			line := fmt.Sprintf("\trequire %s %s", dep.Pkg, dep.Tag)
+			if dep.Indirect {
+				line += " // indirect"
+			}
+			if modFileInfo.IsExcluded(dep.Pkg, dep.Tag) {
+				line += " (excluded)"
+			}
+			if workspace.IsRetracted(dep.Pkg, dep.Tag) {
+				line += " (retracted)"
+			}
+			fmt.Println(line)
+		}
+		printReplaces(modFileInfo.Replaces)
+		for _, exclude := range modFileInfo.Excludes {
+			fmt.Printf("\texclude %s %s\n", exclude.Pkg, exclude.Tag)
+		}
+		for _, retract := range modFileInfo.Retracts {
+			line := "\tretract " + retract.Low
+			if retract.High != retract.Low {
+				line = fmt.Sprintf("\tretract [%s, %s]", retract.Low, retract.High)
+			}
+			if retract.Rationale != "" {
+				line += " // " + retract.Rationale
+			}
+			fmt.Println(line)
+		}
+	}
+	return nil
+}
+
+func printReplaces(replaces []*service.ReplaceMod) {
+	for _, replace := range replaces {
+		old := strings.TrimSpace(replace.OldPkg + " " + replace.OldTag)
+		target := strings.TrimSpace(replace.NewPkg + " " + replace.NewTag)
+		if replace.Dir != "" {
+			target += " (" + replace.Dir + ")"
+		}
+		fmt.Printf("\treplace %s => %s\n", old, target)
+	}
+}
+
+func runExport(ctx context.Context, args []string) error {
+	fs, flags := newFlagSet("export")
+	output := fs.String("o", "", "output file, stdout when empty")
+	if err := flags.parse(fs, args); err != nil {
+		return err
+	}
+	info, err := flags.transverse(ctx)
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		return schema.Encode(os.Stdout, info)
+	}
+	file, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := schema.Encode(file, info); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func runGraph(ctx context.Context, args []string) error {
+	fs, flags := newFlagSet("graph")
+	kind := fs.String("kind", "call", "graph kind: call or struct")
+	output := fs.String("render", "dot", "diagram format: dot or mermaid")
+	options := &render.Options{}
+	fs.StringVar(&options.Root, "root", "", "root node, pkg.Func / pkg.Type.Method / pkg.(*Type).Method for call graph or pkg.Struct for struct graph")
+	fs.IntVar(&options.MaxDepth, "depth", 0, "max depth from -root, 0 means unlimited")
+	fs.BoolVar(&options.CollapseExternal, "collapse-external", false, "collapse packages outside the module into one node, call graphs only contain external calls with -typecheck")
+	fs.BoolVar(&options.HideStdlib, "hide-stdlib", false, "hide standard library nodes, call graphs only contain external calls with -typecheck")
+	if err := flags.parse(fs, args); err != nil {
+		return err
+	}
+	info, err := flags.transverse(ctx)
+	if err != nil {
+		return err
+	}
+	var graph *render.Graph
+	switch *kind {
+	case "call":
+		graph = render.CallGraph(info, options)
+	case "struct":
+		graph = render.StructGraph(info, options)
+	default:
+		return fmt.Errorf("unsupported graph kind %q", *kind)
+	}
+	switch *output {
+	case "dot":
+		return render.DOT(os.Stdout, graph)
+	case "mermaid":
+		return render.Mermaid(os.Stdout, graph)
+	default:
+		return fmt.Errorf("unsupported render format %q", *output)
+	}
+}
+
+func runServe(ctx context.Context, args []string) error {
+	fs, flags := newFlagSet("serve")
+	addr := fs.String("addr", "127.0.0.1:8888", "listen address")
+	if err := flags.parse(fs, args); err != nil {
+		return err
+	}
+	info, err := flags.transverse(ctx)
+	if err != nil {
+		return err
+	}
+	server.NewQueryServer(info, *addr).Spin()
+	return nil
+}
+
+func writeJson(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return errors.New("encode json: " + err.Error())
+	}
+	return nil
+}
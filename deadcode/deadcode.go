@@ -0,0 +1,214 @@
+// Package deadcode 从入口函数出发计算调用图可达性，报告不可达的函数、方法和未被引用的结构体。
+//
+// 可达性依赖访问器采集的三类调用，缺少时会产生误报：包级变量初始化表达式中的调用记在 init#<文件名> 下并作为入口；
+// 类型检查模式下作为值引用的函数(回调注册、函数表)记为 Reference 边；s := &T{} 推断出接收者类型后 s.Method() 才能解析
+package deadcode
+
+import (
+	"ast-callgraph/query"
+	"ast-callgraph/service"
+	"ast-callgraph/vs"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// typeNamePattern 从类型字符串中提取 pkg/path.Type 形式的命名类型
+var typeNamePattern = regexp.MustCompile(`[\w\-./]+\.[A-Za-z_]\w*`)
+
+// Options 入口配置，main、init 和测试函数始终作为入口
+type Options struct {
+	// IncludeExported 导出的函数和方法同样作为入口，适用于库模块
+	IncludeExported bool
+	// Roots 额外指定的入口函数
+	Roots []query.FuncID
+}
+
+// Report 按包分组的死代码报告，包、函数、结构体均按位置排序
+type Report struct {
+	Packages []*PackageReport
+}
+
+// PackageReport 单个包内不可达的函数和未被引用的结构体
+type PackageReport struct {
+	Pkg     string
+	Funcs   []*DeadFunc
+	Structs []*DeadStruct
+}
+
+// DeadFunc 不可达函数，Key 为函数名、Type.Method 或 (*Type).Method
+type DeadFunc struct {
+	Key  string
+	File string
+	Line int
+}
+
+// DeadStruct 未被引用的结构体
+type DeadStruct struct {
+	Name string
+	File string
+	Line int
+}
+
+// Analyze 计算死代码。调用图未覆盖的调用(反射、模块外代码通过接口回调等)会导致误报，结构体引用按类型名称匹配
+func Analyze(info *service.AstTransverseInfo, options *Options) (*Report, error) {
+	if options == nil {
+		options = &Options{}
+	}
+	// 1.收集入口
+	roots := append([]query.FuncID(nil), options.Roots...)
+	for _, goFunc := range info.SortedFuncs() {
+		if isRoot(goFunc, options) {
+			roots = append(roots, query.IDOf(goFunc))
+		}
+	}
+	// 2.计算可达函数
+	reachable, err := query.NewGraph(info).Reachable(roots)
+	if err != nil {
+		return nil, err
+	}
+	reached := make(map[*vs.GoFunc]bool, len(reachable))
+	for _, result := range reachable {
+		reached[result.Func] = true
+	}
+	// 3.汇总不可达函数，匿名函数经所在函数的引用边可达，与普通函数一样统计
+	packages := make(map[string]*PackageReport)
+	packageOf := func(pkg string) *PackageReport {
+		if report, ok := packages[pkg]; ok {
+			return report
+		}
+		report := &PackageReport{
+			Pkg:     pkg,
+			Funcs:   make([]*DeadFunc, 0),
+			Structs: make([]*DeadStruct, 0),
+		}
+		packages[pkg] = report
+		return report
+	}
+	for _, goFunc := range info.SortedFuncs() {
+		if reached[goFunc] {
+			continue
+		}
+		report := packageOf(goFunc.Pkg)
+		report.Funcs = append(report.Funcs, &DeadFunc{
+			Key:  goFunc.Key(),
+			File: goFunc.RFile,
+			Line: goFunc.Begin.Line,
+		})
+	}
+	// 4.汇总未被引用的结构体
+	referenced := referencedTypes(info)
+	for pkg, structInfos := range info.StructInfoMap {
+		for _, structInfo := range structInfos {
+			if referenced[pkg+"."+structInfo.Name] {
+				continue
+			}
+			report := packageOf(pkg)
+			report.Structs = append(report.Structs, &DeadStruct{
+				Name: structInfo.Name,
+				File: structInfo.File,
+				Line: structInfo.StartLine,
+			})
+		}
+	}
+	return sortReport(packages), nil
+}
+
+// isRoot 是否为入口函数
+func isRoot(goFunc *vs.GoFunc, options *Options) bool {
+	if strings.HasPrefix(goFunc.Name, "init#") {
+		return true
+	}
+	// 只有 package main 的 main 是入口，旧版导出结果没有包名时按函数名判断
+	if goFunc.RecvType == nil && goFunc.Name == "main" && (goFunc.PkgName == "main" || goFunc.PkgName == "") {
+		return true
+	}
+	if goFunc.IsTest() {
+		return true
+	}
+	if options.IncludeExported && isExported(goFunc.Name) {
+		return goFunc.RecvType == nil || isExported(receiverName(goFunc))
+	}
+	return false
+}
+
+func receiverName(goFunc *vs.GoFunc) string {
+	_, typeName := vs.SplitTypeName(goFunc.RecvType.Type)
+	return typeName
+}
+
+func isExported(name string) bool {
+	for _, r := range name {
+		return unicode.IsUpper(r)
+	}
+	return false
+}
+
+// referencedTypes 被其他结构体依赖、作为函数参数/返回值/局部变量类型或方法调用接收者出现的类型，
+// 以及包级变量的声明类型和复合字面量构造的类型，如 Register(&impl{}) 和返回接口的构造函数中的 &impl{}
+func referencedTypes(info *service.AstTransverseInfo) map[string]bool {
+	referenced := make(map[string]bool)
+	addTypes := func(typeStr string) {
+		for _, name := range typeNamePattern.FindAllString(typeStr, -1) {
+			referenced[name] = true
+		}
+	}
+	for _, structInfos := range info.StructInfoMap {
+		for _, structInfo := range structInfos {
+			for _, indexes := range structInfo.DepsStructInfo {
+				for _, index := range indexes {
+					if index.Pkg != structInfo.Pkg || index.Name != structInfo.Name {
+						referenced[index.Pkg+"."+index.Name] = true
+					}
+				}
+			}
+		}
+	}
+	for _, goFunc := range info.SortedFuncs() {
+		for _, v := range goFunc.Params {
+			addTypes(v.Type)
+		}
+		for _, v := range goFunc.Results {
+			addTypes(v.Type)
+		}
+		for _, v := range goFunc.TmpVars {
+			addTypes(v.Type)
+		}
+		for _, typeRef := range goFunc.TypeRefs {
+			addTypes(typeRef)
+		}
+		for _, calleeInfo := range goFunc.CalleeInfos {
+			if calleeInfo.Receiver != nil {
+				addTypes(*calleeInfo.Receiver)
+			}
+		}
+	}
+	return referenced
+}
+
+func sortReport(packages map[string]*PackageReport) *Report {
+	report := &Report{
+		Packages: make([]*PackageReport, 0, len(packages)),
+	}
+	for _, pkgReport := range packages {
+		sort.Slice(pkgReport.Funcs, func(i, j int) bool {
+			return lessPosition(pkgReport.Funcs[i].File, pkgReport.Funcs[i].Line, pkgReport.Funcs[j].File, pkgReport.Funcs[j].Line)
+		})
+		sort.Slice(pkgReport.Structs, func(i, j int) bool {
+			return lessPosition(pkgReport.Structs[i].File, pkgReport.Structs[i].Line, pkgReport.Structs[j].File, pkgReport.Structs[j].Line)
+		})
+		report.Packages = append(report.Packages, pkgReport)
+	}
+	sort.Slice(report.Packages, func(i, j int) bool {
+		return report.Packages[i].Pkg < report.Packages[j].Pkg
+	})
+	return report
+}
+
+func lessPosition(fileA string, lineA int, fileB string, lineB int) bool {
+	if fileA != fileB {
+		return fileA < fileB
+	}
+	return lineA < lineB
+}
@@ -0,0 +1,122 @@
+package deadcode
+
+import (
+	"ast-callgraph/internal/testmod"
+	"ast-callgraph/service"
+	"ast-callgraph/service/servicetest"
+	"reflect"
+	"testing"
+)
+
+// deadcodeSource 覆盖访问器为死代码分析补充的三类调用：
+// 包级变量初始化(init#<文件名>)、取地址复合字面量 &T{} 的接收者推断、函数值引用(回调注册和函数表)
+const deadcodeSource = `package main
+
+var defaultStore = newStore()
+
+type store struct{}
+
+func newStore() *store { return &store{} }
+
+type svc struct{}
+
+func (s *svc) run() { register(handler) }
+
+func (s *svc) unused() {}
+
+func register(fn func()) { fn() }
+
+func handler() {}
+
+func orphan() {}
+
+var table = map[string]func(){"a": tableFunc}
+
+func tableFunc() {}
+
+func main() {
+	s := &svc{}
+	s.run()
+	_ = defaultStore
+	table["a"]()
+}
+`
+
+func TestAnalyze(t *testing.T) {
+	dir := testmod.Write(t, map[string]string{"main.go": deadcodeSource})
+	tests := []struct {
+		name      string
+		typeCheck bool
+		want      []string
+	}{
+		// 语法推断模式不记录函数表等非调用位置的函数引用
+		{name: "syntactic", typeCheck: false, want: []string{"(*svc).unused", "orphan", "tableFunc"}},
+		{name: "typecheck", typeCheck: true, want: []string{"(*svc).unused", "orphan"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := servicetest.AnalyzeDir(t, dir, &service.AstTransverseParam{TypeCheck: tt.typeCheck})
+			report, err := Analyze(info, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, 0)
+			for _, pkgReport := range report.Packages {
+				for _, deadFunc := range pkgReport.Funcs {
+					got = append(got, deadFunc.Key)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("dead funcs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// structSource 只经包级变量声明类型、作为参数传入的复合字面量和返回接口的构造函数引用的结构体，不报告为未引用
+const structSource = `package main
+
+type global struct{}
+
+var state global
+
+type registered struct{}
+
+func register(v any) {}
+
+type Store interface{ Get() }
+
+type memStore struct{}
+
+func (*memStore) Get() {}
+
+func newStore() Store { return &memStore{} }
+
+type unused struct{}
+
+func main() {
+	register(&registered{})
+	newStore().Get()
+	_ = state
+}
+`
+
+func TestAnalyzeStructs(t *testing.T) {
+	dir := testmod.Write(t, map[string]string{"main.go": structSource})
+	for _, typeCheck := range []bool{false, true} {
+		info := servicetest.AnalyzeDir(t, dir, &service.AstTransverseParam{TypeCheck: typeCheck})
+		report, err := Analyze(info, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make([]string, 0)
+		for _, pkgReport := range report.Packages {
+			for _, deadStruct := range pkgReport.Structs {
+				got = append(got, deadStruct.Name)
+			}
+		}
+		if want := []string{"unused"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("typecheck=%v: dead structs = %v, want %v", typeCheck, got, want)
+		}
+	}
+}
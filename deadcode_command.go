@@ -0,0 +1,48 @@
+package main
+
+import (
+	"ast-callgraph/deadcode"
+	"ast-callgraph/query"
+	"context"
+	"fmt"
+	"os"
+)
+
+func runDeadCode(ctx context.Context, args []string) error {
+	fs, flags := newFlagSet("deadcode")
+	options := &deadcode.Options{}
+	fs.BoolVar(&options.IncludeExported, "exported", false, "treat exported funcs and methods as entrypoints, for library modules")
+	var roots patternsFlag
+	fs.Var(&roots, "root", "extra entrypoint, pkg/path.Func or pkg/path.Type.Method (repeatable or comma separated)")
+	if err := flags.parse(fs, args); err != nil {
+		return err
+	}
+	info, err := flags.transverse(ctx)
+	if err != nil {
+		return err
+	}
+	for _, root := range roots {
+		id, err := query.ResolveFuncID(info, root)
+		if err != nil {
+			return err
+		}
+		options.Roots = append(options.Roots, id)
+	}
+	report, err := deadcode.Analyze(info, options)
+	if err != nil {
+		return err
+	}
+	if flags.format == formatJson {
+		return writeJson(os.Stdout, report)
+	}
+	for _, pkgReport := range report.Packages {
+		fmt.Println(pkgReport.Pkg)
+		for _, deadFunc := range pkgReport.Funcs {
+			fmt.Printf("\tfunc   %s\t%s:%d\n", deadFunc.Key, deadFunc.File, deadFunc.Line)
+		}
+		for _, deadStruct := range pkgReport.Structs {
+			fmt.Printf("\tstruct %s\t%s:%d\n", deadStruct.Name, deadStruct.File, deadStruct.Line)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package impact
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var hunkHeaderPattern = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// LineRange 新文件中的变更行区间，闭区间
+type LineRange struct {
+	Start int
+	End   int
+}
+
+// FileChange 单个文件的变更，File 为 diff 中的新文件路径，删除的文件不记录
+type FileChange struct {
+	File   string
+	Ranges []LineRange
+}
+
+// ParseUnifiedDiff 解析 unified diff，新增和修改的行计入变更，纯删除记为删除位置所在的行
+func ParseUnifiedDiff(r io.Reader) ([]*FileChange, error) {
+	changes := make([]*FileChange, 0)
+	var current *FileChange
+	newLine, oldRemaining, newRemaining := 0, 0, 0
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// 1.hunk 内按行数消费，避免把内容行误判为文件头
+		if oldRemaining > 0 || newRemaining > 0 {
+			switch {
+			case strings.HasPrefix(line, "+"):
+				if current != nil {
+					current.addLine(newLine)
+				}
+				newLine++
+				newRemaining--
+			case strings.HasPrefix(line, "-"):
+				// 删除的行在新文件中不存在，记为删除位置
+				if current != nil {
+					current.addLine(max(newLine, 1))
+				}
+				oldRemaining--
+			case strings.HasPrefix(line, `\`):
+			default:
+				newLine++
+				oldRemaining--
+				newRemaining--
+			}
+			continue
+		}
+		// 2.文件头和 hunk 头
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			current = nil
+			path := strings.TrimPrefix(line, "+++ ")
+			if idx := strings.Index(path, "\t"); idx >= 0 {
+				path = path[:idx]
+			}
+			path = strings.TrimSpace(path)
+			if path == "/dev/null" {
+				continue
+			}
+			current = &FileChange{File: strings.TrimPrefix(path, "b/")}
+			changes = append(changes, current)
+		case strings.HasPrefix(line, "@@"):
+			match := hunkHeaderPattern.FindStringSubmatch(line)
+			if match == nil {
+				return nil, fmt.Errorf("invalid hunk header %q", line)
+			}
+			newLine, _ = strconv.Atoi(match[3])
+			oldRemaining, newRemaining = hunkLength(match[2]), hunkLength(match[4])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// hunkLength hunk 头中省略的行数默认为 1
+func hunkLength(raw string) int {
+	if raw == "" {
+		return 1
+	}
+	n, _ := strconv.Atoi(raw)
+	return n
+}
+
+// addLine 连续的行合并为同一区间
+func (c *FileChange) addLine(line int) {
+	if n := len(c.Ranges); n > 0 && line >= c.Ranges[n-1].Start && line <= c.Ranges[n-1].End+1 {
+		c.Ranges[n-1].End = max(c.Ranges[n-1].End, line)
+		return
+	}
+	c.Ranges = append(c.Ranges, LineRange{Start: line, End: line})
+}
+
+// GitDiff 通过 git plumbing 命令生成 diff：head 为空时比较 base 与工作区
+func GitDiff(ctx context.Context, dir string, base string, head string) ([]byte, error) {
+	args := []string{"diff-tree", "-p", "--no-color", "--no-ext-diff", "-U0", "-M", "-r", base, head}
+	if head == "" {
+		args = []string{"diff-index", "-p", "--no-color", "--no-ext-diff", "-U0", "-M", base}
+	}
+	return runGit(ctx, dir, args...)
+}
+
+// RelativeToModule 将相对 git 仓库根目录的路径转换为相对模块根目录的路径，模块外的文件被丢弃；modDir 不在 git 仓库中时返回错误
+func RelativeToModule(ctx context.Context, modDir string, changes []*FileChange) ([]*FileChange, error) {
+	topLevel, err := runGit(ctx, modDir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	// git 输出的仓库根目录已解析符号链接
+	if resolved, err := filepath.EvalSymlinks(modDir); err == nil {
+		modDir = resolved
+	}
+	return RelativeTo(strings.TrimSpace(string(topLevel)), modDir, changes)
+}
+
+// RelativeTo 将相对 root 的路径转换为相对模块根目录 modDir 的路径，模块外的文件被丢弃；modDir 不在 root 内时返回错误
+func RelativeTo(root string, modDir string, changes []*FileChange) ([]*FileChange, error) {
+	prefix, err := filepath.Rel(root, modDir)
+	if err != nil {
+		return nil, err
+	}
+	if prefix == ".." || strings.HasPrefix(prefix, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("module dir %s is not under %s", modDir, root)
+	}
+	if prefix == "." {
+		return changes, nil
+	}
+	prefix = filepath.ToSlash(prefix) + "/"
+	result := make([]*FileChange, 0, len(changes))
+	for _, change := range changes {
+		if strings.HasPrefix(change.File, prefix) {
+			result = append(result, &FileChange{
+				File:   strings.TrimPrefix(change.File, prefix),
+				Ranges: change.Ranges,
+			})
+		}
+	}
+	return result, nil
+}
+
+func runGit(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
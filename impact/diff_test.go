@@ -0,0 +1,209 @@
+package impact
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		diff string
+		want []*FileChange
+	}{
+		{
+			name: "modified",
+			diff: `diff --git a/a.go b/a.go
+index 1111111..2222222 100644
+--- a/a.go
++++ b/a.go
+@@ -3,2 +3,3 @@ func A() {
+ 	x := 1
+-	y := 2
++	y := 3
++	z := 4
+@@ -20 +21 @@
+-	return
++	return x
+`,
+			want: []*FileChange{{File: "a.go", Ranges: []LineRange{{Start: 4, End: 5}, {Start: 21, End: 21}}}},
+		},
+		{
+			// 纯删除记为删除位置所在的行，文件开头的删除记为第 1 行
+			name: "pure deletion",
+			diff: `--- a/a.go
++++ b/a.go
+@@ -1,2 +0,0 @@
+-// Deprecated
+-
+@@ -10,3 +8 @@
+ keep
+-gone
+-gone
+`,
+			want: []*FileChange{{File: "a.go", Ranges: []LineRange{{Start: 1, End: 1}, {Start: 9, End: 9}}}},
+		},
+		{
+			name: "new file",
+			diff: `diff --git a/new.go b/new.go
+new file mode 100644
+--- /dev/null
++++ b/new.go
+@@ -0,0 +1,3 @@
++package p
++
++func New() {}
+`,
+			want: []*FileChange{{File: "new.go", Ranges: []LineRange{{Start: 1, End: 3}}}},
+		},
+		{
+			// 删除的文件在新版本中不存在，不记录
+			name: "deleted file",
+			diff: `diff --git a/old.go b/old.go
+deleted file mode 100644
+--- a/old.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package p
+-func Old() {}
+diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -1 +1 @@
+-package a
++package b
+`,
+			want: []*FileChange{{File: "a.go", Ranges: []LineRange{{Start: 1, End: 1}}}},
+		},
+		{
+			// 重命名并修改时按新路径记录，纯重命名没有 hunk，不记录
+			name: "renamed",
+			diff: `diff --git a/old/x.go b/new/x.go
+similarity index 90%
+rename from old/x.go
+rename to new/x.go
+--- a/old/x.go
++++ b/new/x.go
+@@ -5 +5 @@
+-	a()
++	b()
+diff --git a/same.go b/moved.go
+similarity index 100%
+rename from same.go
+rename to moved.go
+`,
+			want: []*FileChange{{File: "new/x.go", Ranges: []LineRange{{Start: 5, End: 5}}}},
+		},
+		{
+			name: "no newline at end of file",
+			diff: `--- a/a.go
++++ b/a.go
+@@ -3 +3,2 @@
+-}
+\ No newline at end of file
++}
++
+`,
+			want: []*FileChange{{File: "a.go", Ranges: []LineRange{{Start: 3, End: 4}}}},
+		},
+		{
+			name: "no newline on both sides",
+			diff: `--- a/a.go
++++ b/a.go
+@@ -7 +7 @@
+-	return 1 }
+\ No newline at end of file
++	return 2 }
+\ No newline at end of file
+--- a/b.go
++++ b/b.go
+@@ -1 +1 @@
+-x
++y
+`,
+			want: []*FileChange{
+				{File: "a.go", Ranges: []LineRange{{Start: 7, End: 7}}},
+				{File: "b.go", Ranges: []LineRange{{Start: 1, End: 1}}},
+			},
+		},
+		{
+			// hunk 内以 "--- "、"+++ " 开头的内容行不能当作文件头
+			name: "content looks like header",
+			diff: `--- a/a.go
++++ b/a.go
+@@ -1,2 +1,2 @@
+--- a comment
++++ a counter
+ keep
+`,
+			want: []*FileChange{{File: "a.go", Ranges: []LineRange{{Start: 1, End: 1}}}},
+		},
+		{
+			// 非 git 生成的 diff 文件名后带时间戳
+			name: "timestamp",
+			diff: "--- a.go\t2024-01-01 00:00:00\n+++ a.go\t2024-01-02 00:00:00\n@@ -1 +1 @@\n-a\n+b\n",
+			want: []*FileChange{{File: "a.go", Ranges: []LineRange{{Start: 1, End: 1}}}},
+		},
+	}
+	for _, tt := range tests {
+		got, err := ParseUnifiedDiff(strings.NewReader(tt.diff))
+		if err != nil {
+			t.Errorf("%s: err = %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %s, want %s", tt.name, formatChanges(got), formatChanges(tt.want))
+		}
+	}
+}
+
+func TestParseUnifiedDiffInvalidHunk(t *testing.T) {
+	if _, err := ParseUnifiedDiff(strings.NewReader("--- a/a.go\n+++ b/a.go\n@@ bad @@\n")); err == nil {
+		t.Error("invalid hunk header: want error")
+	}
+}
+
+func formatChanges(changes []*FileChange) string {
+	parts := make([]string, 0, len(changes))
+	for _, change := range changes {
+		parts = append(parts, fmt.Sprintf("%s%v", change.File, change.Ranges))
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
+func TestRelativeTo(t *testing.T) {
+	changes := []*FileChange{
+		{File: "mod/a.go", Ranges: []LineRange{{Start: 1, End: 2}}},
+		{File: "other/b.go", Ranges: []LineRange{{Start: 3, End: 3}}},
+	}
+	tests := []struct {
+		name    string
+		root    string
+		modDir  string
+		want    string
+		wantErr bool
+	}{
+		{name: "subdir", root: "/repo", modDir: "/repo/mod", want: "[a.go[{1 2}]]"},
+		{name: "same dir", root: "/repo", modDir: "/repo", want: "[mod/a.go[{1 2}] other/b.go[{3 3}]]"},
+		{name: "outside root", root: "/repo/mod", modDir: "/repo", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := RelativeTo(tt.root, tt.modDir, changes)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && formatChanges(got) != tt.want {
+			t.Errorf("%s: changes = %s, want %s", tt.name, formatChanges(got), tt.want)
+		}
+	}
+}
+
+func TestRelativeToModuleOutsideGit(t *testing.T) {
+	if _, err := RelativeToModule(context.Background(), t.TempDir(), nil); err == nil {
+		t.Error("dir outside git repository: want error")
+	}
+}
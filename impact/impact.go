@@ -0,0 +1,205 @@
+// Package impact 将 diff 的变更行映射到函数和结构体，沿调用方传递计算受影响的函数、HTTP 处理函数和测试
+package impact
+
+import (
+	"ast-callgraph/query"
+	"ast-callgraph/service"
+	"ast-callgraph/vs"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// defaultHandlerParamTypes 参数含有这些类型的函数视为 HTTP 处理函数
+var defaultHandlerParamTypes = []string{
+	"net/http.ResponseWriter",
+	"net/http.Request",
+	"github.com/cloudwego/hertz/pkg/app.RequestContext",
+	"github.com/gin-gonic/gin.Context",
+	"github.com/labstack/echo/v4.Context",
+	"github.com/gofiber/fiber/v2.Ctx",
+}
+
+var typeNamePattern = regexp.MustCompile(`[\w\-./]+\.[A-Za-z_]\w*`)
+
+// Options 影响分析配置
+type Options struct {
+	// HandlerParamTypes 额外的 HTTP 处理函数参数类型，pkg/path.Type 形式
+	HandlerParamTypes []string
+}
+
+// Report 影响分析结果，列表均按包名和函数标识排序
+type Report struct {
+	ChangedFuncs   []*FuncRef   `json:"changedFuncs"`
+	ChangedStructs []*StructRef `json:"changedStructs"`
+	Impacted       []*FuncRef   `json:"impacted"`
+	Handlers       []*FuncRef   `json:"handlers"`
+	Tests          []*FuncRef   `json:"tests"`
+}
+
+// FuncRef 受影响的函数，Depth 为距最近变更函数的调用跳数，Via 为该变更函数
+type FuncRef struct {
+	Func  string `json:"func"`
+	File  string `json:"file"`
+	Line  int    `json:"line"`
+	Depth int    `json:"depth"`
+	Via   string `json:"via,omitempty"`
+}
+
+// StructRef 变更的结构体
+type StructRef struct {
+	Struct string `json:"struct"`
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+}
+
+// Analyze 计算变更影响，changes 中的文件路径需相对模块根目录
+func Analyze(info *service.AstTransverseInfo, changes []*FileChange, options *Options) (*Report, error) {
+	if options == nil {
+		options = &Options{}
+	}
+	changedLines := make(map[string][]LineRange, len(changes))
+	for _, change := range changes {
+		changedLines[change.File] = append(changedLines[change.File], change.Ranges...)
+	}
+	report := &Report{
+		ChangedFuncs:   make([]*FuncRef, 0),
+		ChangedStructs: make([]*StructRef, 0),
+		Impacted:       make([]*FuncRef, 0),
+		Handlers:       make([]*FuncRef, 0),
+		Tests:          make([]*FuncRef, 0),
+	}
+	// 1.变更行映射到函数
+	seeds := make(map[*vs.GoFunc]bool)
+	for _, goFunc := range info.SortedFuncs() {
+		if overlaps(changedLines[filepath.ToSlash(goFunc.RFile)], goFunc.Begin.Line, goFunc.End.Line) {
+			seeds[goFunc] = true
+			report.ChangedFuncs = append(report.ChangedFuncs, newFuncRef(goFunc, 0, nil))
+		}
+	}
+	// 2.变更行映射到结构体，结构体的方法和使用该类型的函数视为受影响
+	changedTypes := make(map[string]bool)
+	for pkg, structInfos := range info.StructInfoMap {
+		for _, structInfo := range structInfos {
+			if overlaps(changedLines[filepath.ToSlash(structInfo.File)], structInfo.StartLine, structInfo.EndLine) {
+				changedTypes[pkg+"."+structInfo.Name] = true
+				report.ChangedStructs = append(report.ChangedStructs, &StructRef{
+					Struct: pkg + "." + structInfo.Name,
+					File:   structInfo.File,
+					Line:   structInfo.StartLine,
+				})
+			}
+		}
+	}
+	sort.Slice(report.ChangedStructs, func(i, j int) bool {
+		return report.ChangedStructs[i].Struct < report.ChangedStructs[j].Struct
+	})
+	if len(changedTypes) > 0 {
+		for _, goFunc := range info.SortedFuncs() {
+			if !seeds[goFunc] && usesTypes(goFunc, changedTypes) {
+				seeds[goFunc] = true
+				report.ChangedFuncs = append(report.ChangedFuncs, newFuncRef(goFunc, 0, nil))
+			}
+		}
+	}
+	// 3.沿调用方传递，保留距变更函数最近的一条
+	graph := query.NewGraph(info)
+	impacted := make(map[*vs.GoFunc]*FuncRef)
+	for _, seed := range sortedFuncs(seeds) {
+		impacted[seed] = newFuncRef(seed, 0, nil)
+	}
+	for _, seed := range sortedFuncs(seeds) {
+		results, err := graph.Callers(query.IDOf(seed), 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range results {
+			if existing, ok := impacted[result.Func]; ok && existing.Depth <= result.Depth {
+				continue
+			}
+			impacted[result.Func] = newFuncRef(result.Func, result.Depth, seed)
+		}
+	}
+	// 4.分类输出
+	handlerTypes := append(append([]string(nil), defaultHandlerParamTypes...), options.HandlerParamTypes...)
+	for _, goFunc := range sortedFuncs(impacted) {
+		ref := impacted[goFunc]
+		report.Impacted = append(report.Impacted, ref)
+		if isHandler(goFunc, handlerTypes) {
+			report.Handlers = append(report.Handlers, ref)
+		}
+		if goFunc.IsTest() {
+			report.Tests = append(report.Tests, ref)
+		}
+	}
+	return report, nil
+}
+
+func overlaps(ranges []LineRange, start int, end int) bool {
+	for _, r := range ranges {
+		if r.Start <= end && r.End >= start {
+			return true
+		}
+	}
+	return false
+}
+
+// usesTypes 函数的接收者、参数、返回值或局部变量是否使用了指定类型
+func usesTypes(goFunc *vs.GoFunc, typeNames map[string]bool) bool {
+	vars := append(append([]*vs.Var(nil), goFunc.Params...), goFunc.Results...)
+	if goFunc.RecvType != nil {
+		vars = append(vars, goFunc.RecvType)
+	}
+	for _, v := range goFunc.TmpVars {
+		vars = append(vars, v)
+	}
+	for _, v := range vars {
+		for _, name := range typeNamePattern.FindAllString(v.Type, -1) {
+			if typeNames[name] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isHandler 参数中含有 HTTP 框架请求上下文类型
+func isHandler(goFunc *vs.GoFunc, handlerTypes []string) bool {
+	for _, param := range goFunc.Params {
+		paramType := strings.TrimLeft(param.Type, "*")
+		for _, handlerType := range handlerTypes {
+			if paramType == handlerType {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func newFuncRef(goFunc *vs.GoFunc, depth int, via *vs.GoFunc) *FuncRef {
+	ref := &FuncRef{
+		Func:  query.IDOf(goFunc).String(),
+		File:  goFunc.RFile,
+		Line:  goFunc.Begin.Line,
+		Depth: depth,
+	}
+	if via != nil {
+		ref.Via = query.IDOf(via).String()
+	}
+	return ref
+}
+
+func sortedFuncs[V any](funcs map[*vs.GoFunc]V) []*vs.GoFunc {
+	result := make([]*vs.GoFunc, 0, len(funcs))
+	for goFunc := range funcs {
+		result = append(result, goFunc)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Pkg != result[j].Pkg {
+			return result[i].Pkg < result[j].Pkg
+		}
+		return result[i].Key() < result[j].Key()
+	})
+	return result
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"ast-callgraph/impact"
+	"ast-callgraph/schema"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+)
+
+func runImpact(ctx context.Context, args []string) error {
+	fs, flags := newFlagSet("impact")
+	diffFile := fs.String("diff", "", "unified diff file, '-' reads stdin")
+	diffRoot := fs.String("diff-root", "", "directory the -diff paths are relative to, defaults to the git repository root of the module")
+	base := fs.String("base", "", "git revision to diff from, used when -diff is empty")
+	head := fs.String("head", "", "git revision to diff to, empty compares -base with the working tree")
+	options := &impact.Options{}
+	var handlerTypes patternsFlag
+	fs.Var(&handlerTypes, "handler-type", "extra param type marking HTTP handlers, pkg/path.Type (repeatable or comma separated)")
+	if err := flags.parse(fs, args); err != nil {
+		return err
+	}
+	if (*diffFile == "") == (*base == "") {
+		return errors.New("exactly one of -diff and -base is required")
+	}
+	if *diffRoot != "" && *diffFile == "" {
+		return errors.New("-diff-root requires -diff")
+	}
+	options.HandlerParamTypes = handlerTypes
+	info, err := flags.transverse(ctx)
+	if err != nil {
+		return err
+	}
+	modDir := schema.ModuleDir(info)
+	// 1.读取 diff，路径相对仓库根目录或 -diff-root，需要转换为相对模块根目录
+	var diff []byte
+	fromGit := *diffFile == ""
+	switch {
+	case fromGit:
+		diff, err = impact.GitDiff(ctx, modDir, *base, *head)
+	case *diffFile == "-":
+		diff, err = io.ReadAll(os.Stdin)
+	default:
+		diff, err = os.ReadFile(*diffFile)
+	}
+	if err != nil {
+		return err
+	}
+	changes, err := impact.ParseUnifiedDiff(bytes.NewReader(diff))
+	if err != nil {
+		return err
+	}
+	fileCnt := len(changes)
+	if *diffRoot != "" {
+		root, err := filepath.Abs(*diffRoot)
+		if err != nil {
+			return err
+		}
+		if changes, err = impact.RelativeTo(root, modDir, changes); err != nil {
+			return err
+		}
+	} else if changes, err = impact.RelativeToModule(ctx, modDir, changes); err != nil {
+		if fromGit {
+			return err
+		}
+		return fmt.Errorf("resolve -diff paths: %w, use -diff-root outside git repositories", err)
+	}
+	if fileCnt > 0 && len(changes) == 0 {
+		hlog.CtxWarnf(ctx, "none of the %d changed files is under %s, check the path base of the diff", fileCnt, modDir)
+	}
+	// 2.分析并输出
+	report, err := impact.Analyze(info, changes, options)
+	if err != nil {
+		return err
+	}
+	if flags.format == formatJson {
+		return writeJson(os.Stdout, report)
+	}
+	fmt.Println("changed structs:")
+	for _, ref := range report.ChangedStructs {
+		fmt.Printf("\t%s\t%s:%d\n", ref.Struct, ref.File, ref.Line)
+	}
+	printFuncRefs("changed funcs:", report.ChangedFuncs)
+	printFuncRefs("impacted funcs:", report.Impacted)
+	printFuncRefs("impacted handlers:", report.Handlers)
+	printFuncRefs("impacted tests:", report.Tests)
+	return nil
+}
+
+func printFuncRefs(title string, refs []*impact.FuncRef) {
+	fmt.Println(title)
+	for _, ref := range refs {
+		if ref.Via != "" {
+			fmt.Printf("\t%s\t%s:%d\tdepth %d via %s\n", ref.Func, ref.File, ref.Line, ref.Depth, ref.Via)
+		} else {
+			fmt.Printf("\t%s\t%s:%d\n", ref.Func, ref.File, ref.Line)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+// Package testmod 测试用的临时模块
+package testmod
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// GoMod 未提供 go.mod 时使用的模块声明
+const GoMod = "module example.com/m\n\ngo 1.21\n"
+
+// Write 在临时目录写入模块文件并返回目录，files 为相对模块根目录的路径 -> 内容，未提供 go.mod 时使用 GoMod
+func Write(t testing.TB, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if _, ok := files["go.mod"]; !ok {
+		WriteFile(t, dir, "go.mod", GoMod)
+	}
+	for name, content := range files {
+		WriteFile(t, dir, name, content)
+	}
+	return dir
+}
+
+// WriteFile 写入 dir 下的单个文件，按需创建上级目录
+func WriteFile(t testing.TB, dir string, name string, content string) {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
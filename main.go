@@ -1,27 +1,54 @@
 package main
 
 import (
-	"ast-callgraph/service"
 	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
 	"time"
 
 	"github.com/cloudwego/hertz/pkg/common/hlog"
 )
 
+const usage = `usage: ast-callgraph <command> [flags]
+
+commands:
+  structs    list struct definitions and their struct dependencies
+  interfaces list interfaces and the in-module structs implementing them
+  callgraph  list resolved call edges
+  deps       list workspace modules and their dependencies from go.mod
+  query      transitive callers/callees, paths between funcs and reachability from entrypoints
+  deadcode   report funcs unreachable from main/init/tests/roots and unreferenced structs
+  impact     map a diff or git revisions onto funcs/structs and list impacted funcs, handlers and tests
+  tests      list production funcs reached by each test and funcs no test reaches
+  platforms  compare call graphs across GOOS/GOARCH pairs and list funcs and edges missing on some platforms
+  graph      render call graph or struct dependency graph as Graphviz DOT or Mermaid
+  serve      analyze once and serve queries over HTTP
+  export     write the full analysis result as versioned JSON (see package schema)
+
+run "ast-callgraph <command> -h" for command flags
+`
+
 func main() {
 	ctx := context.Background()
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	command, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
 	start := time.Now()
-	defer func() {
-		hlog.CtxInfof(ctx, "exec cost %.2f", time.Since(start).Seconds())
-	}()
-	goModPath := "/Users/silhouette/work-practice/ast-callgraph/go.mod"
-	astTransverseInfo, err := service.TransverseDirectory(ctx, &service.AstTransverseParam{
-		Directory: "/Users/silhouette/work-practice/ast-callgraph",
-		GoModPath: &goModPath,
-	})
-	if err != nil {
-		hlog.CtxInfof(ctx, "TransverseDirectory err: %v", err)
+	err := command(ctx, os.Args[2:])
+	hlog.CtxInfof(ctx, "exec cost %.2f", time.Since(start).Seconds())
+	if errors.Is(err, flag.ErrHelp) {
 		return
 	}
-	hlog.CtxInfof(ctx, "TransverseDirectory success %v", astTransverseInfo)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ast-callgraph %s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
 }
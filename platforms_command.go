@@ -0,0 +1,46 @@
+package main
+
+import (
+	"ast-callgraph/service"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+func runPlatforms(ctx context.Context, args []string) error {
+	fs, flags := newFlagSet("platforms")
+	var platformsFlag patternsFlag
+	fs.Var(&platformsFlag, "platforms", "GOOS/GOARCH pairs to compare, at least two (repeatable or comma separated)")
+	if err := flags.parse(fs, args); err != nil {
+		return err
+	}
+	if len(platformsFlag) < 2 {
+		return errors.New("-platforms requires at least two GOOS/GOARCH pairs")
+	}
+	platforms := make([]service.Platform, 0, len(platformsFlag))
+	for _, s := range platformsFlag {
+		platform, err := service.ParsePlatform(s)
+		if err != nil {
+			return err
+		}
+		platforms = append(platforms, platform)
+	}
+	diff, err := service.DiffPlatforms(ctx, flags.transverseParam(), platforms)
+	if err != nil {
+		return fmt.Errorf("analyze %s: %w", flags.directory, err)
+	}
+	if flags.format == formatJson {
+		return writeJson(os.Stdout, diff)
+	}
+	fmt.Println("funcs:")
+	for _, f := range diff.Funcs {
+		fmt.Printf("\t%s\t%s:%d\t[%s]\n", f.Func, f.File, f.Line, strings.Join(f.Platforms, " "))
+	}
+	fmt.Println("call edges:")
+	for _, e := range diff.Edges {
+		fmt.Printf("\t%s -> %s\t%s:%d\t[%s]\n", e.Caller, e.Callee, e.File, e.Line, strings.Join(e.Platforms, " "))
+	}
+	return nil
+}
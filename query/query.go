@@ -0,0 +1,282 @@
+// Package query 基于已解析调用边的调用关系查询：直接/传递调用方与被调用方、两函数间的全部简单路径、入口可达性
+package query
+
+import (
+	"ast-callgraph/service"
+	"ast-callgraph/vs"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FuncID 函数标识，Key 为函数名、Type.Method 或 (*Type).Method
+type FuncID struct {
+	Pkg string
+	Key string
+}
+
+// ParseFuncID 解析 pkg/path.Key 形式的函数标识，包路径最后一段之后的第一个"."为分隔，
+// 最后一段为 gopkg.in 风格的 name.vN 时跳过版本后缀；包路径本身含"."时应使用 ResolveFuncID
+func ParseFuncID(s string) (FuncID, error) {
+	slash := strings.LastIndex(s, "/")
+	dot := strings.Index(s[slash+1:], ".")
+	if dot < 0 {
+		return FuncID{}, fmt.Errorf("invalid func id %q, expect pkg/path.Func or pkg/path.Type.Method", s)
+	}
+	dot += slash + 1
+	if version := strings.Index(s[dot+1:], "."); version > 0 && vs.IsMajorVersion(s[dot+1:dot+1+version]) {
+		dot += version + 1
+	}
+	return FuncID{
+		Pkg: s[:dot],
+		Key: s[dot+1:],
+	}, nil
+}
+
+// ResolveFuncID 按分析结果中已知的包路径解析函数标识：优先取能查到函数的最长包路径前缀，其次取最长的已知包路径前缀，
+// 均不匹配时退回 ParseFuncID。已知包包括模块内的包和被调用的外部包，可正确切分 example.com.F、gopkg.in/yaml.v3.Marshal 等
+func ResolveFuncID(info *service.AstTransverseInfo, s string) (FuncID, error) {
+	var found, known FuncID
+	for _, pkg := range knownPkgs(info) {
+		if len(pkg) >= len(s)-1 || !strings.HasPrefix(s, pkg+".") {
+			continue
+		}
+		id := FuncID{Pkg: pkg, Key: s[len(pkg)+1:]}
+		if len(pkg) > len(known.Pkg) {
+			known = id
+		}
+		if len(pkg) > len(found.Pkg) && info.GetFunc(id.Pkg, id.Key) != nil {
+			found = id
+		}
+	}
+	switch {
+	case found.Pkg != "":
+		return found, nil
+	case known.Pkg != "":
+		return known, nil
+	default:
+		return ParseFuncID(s)
+	}
+}
+
+// knownPkgs 定义了函数的包和调用点引用的包
+func knownPkgs(info *service.AstTransverseInfo) []string {
+	seen := make(map[string]bool, len(info.FuncInfoMap))
+	pkgs := make([]string, 0, len(info.FuncInfoMap))
+	add := func(pkg string) {
+		if pkg != "" && !seen[pkg] {
+			seen[pkg] = true
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	for _, goFunc := range info.SortedFuncs() {
+		add(goFunc.Pkg)
+		for _, calleeInfo := range goFunc.CalleeInfos {
+			add(calleeInfo.Pkg)
+		}
+	}
+	return pkgs
+}
+
+func (id FuncID) String() string {
+	return id.Pkg + "." + id.Key
+}
+
+// IDOf 函数的标识
+func IDOf(goFunc *vs.GoFunc) FuncID {
+	return FuncID{
+		Pkg: goFunc.Pkg,
+		Key: goFunc.Key(),
+	}
+}
+
+// Hop 一跳调用，同一对函数间的多个调用点合并
+type Hop struct {
+	Caller    *vs.GoFunc
+	Callee    *vs.GoFunc
+	CallSites []*vs.CalleeInfo
+	// Dynamic 所有调用点均为接口动态分派
+	Dynamic bool
+}
+
+// Result 查询到的函数，Path 为从查询起点出发经过的调用，Depth 为跳数
+type Result struct {
+	Func  *vs.GoFunc
+	Depth int
+	Path  []*Hop
+}
+
+// Graph 按函数聚合的调用图
+type Graph struct {
+	info *service.AstTransverseInfo
+	out  map[*vs.GoFunc][]*Hop
+	in   map[*vs.GoFunc][]*Hop
+}
+
+// NewGraph 由分析结果的调用边构造调用图，边按函数标识排序保证结果稳定
+func NewGraph(info *service.AstTransverseInfo) *Graph {
+	g := &Graph{
+		info: info,
+		out:  make(map[*vs.GoFunc][]*Hop),
+		in:   make(map[*vs.GoFunc][]*Hop),
+	}
+	hops := make(map[*vs.GoFunc]map[*vs.GoFunc]*Hop)
+	for _, edge := range info.CallEdges {
+		if _, ok := hops[edge.Caller]; !ok {
+			hops[edge.Caller] = make(map[*vs.GoFunc]*Hop)
+		}
+		hop, ok := hops[edge.Caller][edge.Callee]
+		if !ok {
+			hop = &Hop{
+				Caller:  edge.Caller,
+				Callee:  edge.Callee,
+				Dynamic: true,
+			}
+			hops[edge.Caller][edge.Callee] = hop
+			g.out[edge.Caller] = append(g.out[edge.Caller], hop)
+			g.in[edge.Callee] = append(g.in[edge.Callee], hop)
+		}
+		hop.CallSites = append(hop.CallSites, edge.CallSite)
+		hop.Dynamic = hop.Dynamic && edge.Dynamic
+	}
+	for _, list := range g.out {
+		sort.Slice(list, func(i, j int) bool {
+			return lessFunc(list[i].Callee, list[j].Callee)
+		})
+	}
+	for _, list := range g.in {
+		sort.Slice(list, func(i, j int) bool {
+			return lessFunc(list[i].Caller, list[j].Caller)
+		})
+	}
+	return g
+}
+
+// Func 查找函数
+func (g *Graph) Func(id FuncID) (*vs.GoFunc, error) {
+	goFunc := g.info.GetFunc(id.Pkg, id.Key)
+	if goFunc == nil {
+		return nil, fmt.Errorf("func %s not found", id)
+	}
+	return goFunc, nil
+}
+
+// Callers 直接和传递调用方，depth 为最大跳数，<=0 不限制；Path 中每一跳的 Callee 更靠近查询起点
+func (g *Graph) Callers(id FuncID, depth int) ([]*Result, error) {
+	start, err := g.Func(id)
+	if err != nil {
+		return nil, err
+	}
+	return g.bfs([]*vs.GoFunc{start}, depth, func(goFunc *vs.GoFunc) []*Hop {
+		return g.in[goFunc]
+	}, func(hop *Hop) *vs.GoFunc {
+		return hop.Caller
+	}), nil
+}
+
+// Callees 直接和传递被调用方，depth 为最大跳数，<=0 不限制
+func (g *Graph) Callees(id FuncID, depth int) ([]*Result, error) {
+	start, err := g.Func(id)
+	if err != nil {
+		return nil, err
+	}
+	return g.bfs([]*vs.GoFunc{start}, depth, g.outHops, calleeOf), nil
+}
+
+// Reachable 从入口函数出发可达的全部函数(含入口本身)，Path 为距最近入口的最短调用链
+func (g *Graph) Reachable(entrypoints []FuncID) ([]*Result, error) {
+	starts := make([]*vs.GoFunc, 0, len(entrypoints))
+	for _, id := range entrypoints {
+		start, err := g.Func(id)
+		if err != nil {
+			return nil, err
+		}
+		starts = append(starts, start)
+	}
+	results := make([]*Result, 0, len(starts))
+	for _, start := range starts {
+		results = append(results, &Result{Func: start})
+	}
+	return append(results, g.bfs(starts, 0, g.outHops, calleeOf)...), nil
+}
+
+// Paths 两个函数之间的全部简单路径，maxDepth 为路径最大跳数，<=0 不限制；调用图较大时应限制深度
+func (g *Graph) Paths(from FuncID, to FuncID, maxDepth int) ([][]*Hop, error) {
+	start, err := g.Func(from)
+	if err != nil {
+		return nil, err
+	}
+	target, err := g.Func(to)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([][]*Hop, 0)
+	onPath := map[*vs.GoFunc]bool{start: true}
+	path := make([]*Hop, 0)
+	var dfs func(current *vs.GoFunc)
+	dfs = func(current *vs.GoFunc) {
+		for _, hop := range g.out[current] {
+			if hop.Callee == target {
+				paths = append(paths, append(append([]*Hop(nil), path...), hop))
+				continue
+			}
+			if onPath[hop.Callee] || (maxDepth > 0 && len(path)+1 >= maxDepth) {
+				continue
+			}
+			onPath[hop.Callee] = true
+			path = append(path, hop)
+			dfs(hop.Callee)
+			path = path[:len(path)-1]
+			onPath[hop.Callee] = false
+		}
+	}
+	dfs(start)
+	return paths, nil
+}
+
+// bfs 广度优先遍历，起点本身不计入结果
+func (g *Graph) bfs(starts []*vs.GoFunc, depth int, next func(*vs.GoFunc) []*Hop, nodeOf func(*Hop) *vs.GoFunc) []*Result {
+	visited := make(map[*vs.GoFunc]bool, len(starts))
+	frontier := make([]*Result, 0, len(starts))
+	for _, start := range starts {
+		visited[start] = true
+		frontier = append(frontier, &Result{Func: start})
+	}
+	results := make([]*Result, 0)
+	for level := 1; len(frontier) > 0 && (depth <= 0 || level <= depth); level++ {
+		nextFrontier := make([]*Result, 0)
+		for _, current := range frontier {
+			for _, hop := range next(current.Func) {
+				node := nodeOf(hop)
+				if visited[node] {
+					continue
+				}
+				visited[node] = true
+				result := &Result{
+					Func:  node,
+					Depth: level,
+					Path:  append(append(make([]*Hop, 0, level), current.Path...), hop),
+				}
+				results = append(results, result)
+				nextFrontier = append(nextFrontier, result)
+			}
+		}
+		frontier = nextFrontier
+	}
+	return results
+}
+
+func (g *Graph) outHops(goFunc *vs.GoFunc) []*Hop {
+	return g.out[goFunc]
+}
+
+func calleeOf(hop *Hop) *vs.GoFunc {
+	return hop.Callee
+}
+
+func lessFunc(a *vs.GoFunc, b *vs.GoFunc) bool {
+	if a.Pkg != b.Pkg {
+		return a.Pkg < b.Pkg
+	}
+	return a.Key() < b.Key()
+}
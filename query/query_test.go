@@ -0,0 +1,170 @@
+package query
+
+import (
+	"ast-callgraph/service"
+	"ast-callgraph/vs"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testPkg = "example.com"
+
+// newTestGraph a -> b -> c -> a 构成环，a -> c，c -> d，d 调用外部包 gopkg.in/yaml.v3
+func newTestGraph(t *testing.T) *Graph {
+	t.Helper()
+	calls := map[string][]string{
+		"a": {"b", "c"},
+		"b": {"c"},
+		"c": {"a", "d"},
+		"d": {},
+	}
+	funcs := make(map[string]*vs.GoFunc, len(calls))
+	for name, callees := range calls {
+		goFunc := &vs.GoFunc{Pkg: testPkg, Name: name}
+		for _, callee := range callees {
+			goFunc.CalleeInfos = append(goFunc.CalleeInfos, &vs.CalleeInfo{Pkg: testPkg, Name: callee})
+		}
+		funcs[name] = goFunc
+	}
+	funcs["d"].CalleeInfos = append(funcs["d"].CalleeInfos, &vs.CalleeInfo{Pkg: "gopkg.in/yaml.v3", Name: "Marshal"})
+	info := &service.AstTransverseInfo{
+		FuncInfoMap: map[string]map[string]*vs.GoFunc{testPkg: funcs},
+	}
+	info.BuildCallGraph()
+	return NewGraph(info)
+}
+
+func TestParseFuncID(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    FuncID
+		wantErr bool
+	}{
+		{in: "example.com/pkg.F", want: FuncID{Pkg: "example.com/pkg", Key: "F"}},
+		{in: "example.com/pkg.T.M", want: FuncID{Pkg: "example.com/pkg", Key: "T.M"}},
+		{in: "example.com/pkg.(*T).M", want: FuncID{Pkg: "example.com/pkg", Key: "(*T).M"}},
+		{in: "gopkg.in/yaml.v3.Marshal", want: FuncID{Pkg: "gopkg.in/yaml.v3", Key: "Marshal"}},
+		{in: "gopkg.in/yaml.v3.Node.Decode", want: FuncID{Pkg: "gopkg.in/yaml.v3", Key: "Node.Decode"}},
+		{in: "example.com/pkg.v0.F", want: FuncID{Pkg: "example.com/pkg", Key: "v0.F"}},
+		{in: "main.main", want: FuncID{Pkg: "main", Key: "main"}},
+		{in: "example.com/pkg", wantErr: true},
+		{in: "F", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseFuncID(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseFuncID(%q) err = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseFuncID(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestResolveFuncID(t *testing.T) {
+	g := newTestGraph(t)
+	tests := []struct {
+		in   string
+		want FuncID
+	}{
+		// 包路径不含 "/" 但含 "."，启发式切分会在第一个 "." 处出错
+		{in: "example.com.a", want: FuncID{Pkg: testPkg, Key: "a"}},
+		// 已知包中不存在的函数仍按最长的已知包路径切分，由查询报告 not found
+		{in: "example.com.missing", want: FuncID{Pkg: testPkg, Key: "missing"}},
+		// 调用点引用的外部包同样作为已知包
+		{in: "gopkg.in/yaml.v3.Marshal", want: FuncID{Pkg: "gopkg.in/yaml.v3", Key: "Marshal"}},
+		// 未知包退回启发式切分
+		{in: "other.org/pkg.F", want: FuncID{Pkg: "other.org/pkg", Key: "F"}},
+	}
+	for _, tt := range tests {
+		got, err := ResolveFuncID(g.info, tt.in)
+		if err != nil {
+			t.Errorf("ResolveFuncID(%q) err = %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ResolveFuncID(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCalleesAndCallers(t *testing.T) {
+	g := newTestGraph(t)
+	tests := []struct {
+		name  string
+		query func(FuncID, int) ([]*Result, error)
+		start string
+		depth int
+		want  []string
+	}{
+		{name: "callees depth 1", query: g.Callees, start: "a", depth: 1, want: []string{"b@1", "c@1"}},
+		{name: "callees depth 2", query: g.Callees, start: "a", depth: 2, want: []string{"b@1", "c@1", "d@2"}},
+		// 环上的起点不再出现在结果中
+		{name: "callees unlimited", query: g.Callees, start: "b", depth: 0, want: []string{"c@1", "a@2", "d@2"}},
+		{name: "callers depth 1", query: g.Callers, start: "c", depth: 1, want: []string{"a@1", "b@1"}},
+		{name: "callers unlimited", query: g.Callers, start: "d", depth: 0, want: []string{"c@1", "a@2", "b@2"}},
+		{name: "leaf", query: g.Callees, start: "d", depth: 0, want: []string{}},
+	}
+	for _, tt := range tests {
+		results, err := tt.query(FuncID{Pkg: testPkg, Key: tt.start}, tt.depth)
+		if err != nil {
+			t.Errorf("%s: err = %v", tt.name, err)
+			continue
+		}
+		got := make([]string, 0, len(results))
+		for _, result := range results {
+			if len(result.Path) != result.Depth {
+				t.Errorf("%s: %s path has %d hops, want %d", tt.name, result.Func.Name, len(result.Path), result.Depth)
+			}
+			got = append(got, fmt.Sprintf("%s@%d", result.Func.Name, result.Depth))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPaths(t *testing.T) {
+	g := newTestGraph(t)
+	tests := []struct {
+		from     string
+		to       string
+		maxDepth int
+		want     []string
+	}{
+		{from: "a", to: "d", maxDepth: 0, want: []string{"a>b>c>d", "a>c>d"}},
+		{from: "a", to: "d", maxDepth: 2, want: []string{"a>c>d"}},
+		{from: "a", to: "d", maxDepth: 1, want: []string{}},
+		// 回到起点的环是一条路径，但不会沿环重复展开
+		{from: "a", to: "a", maxDepth: 0, want: []string{"a>b>c>a", "a>c>a"}},
+		{from: "d", to: "a", maxDepth: 0, want: []string{}},
+	}
+	for _, tt := range tests {
+		paths, err := g.Paths(FuncID{Pkg: testPkg, Key: tt.from}, FuncID{Pkg: testPkg, Key: tt.to}, tt.maxDepth)
+		if err != nil {
+			t.Errorf("Paths(%s, %s, %d) err = %v", tt.from, tt.to, tt.maxDepth, err)
+			continue
+		}
+		got := make([]string, 0, len(paths))
+		for _, path := range paths {
+			names := []string{path[0].Caller.Name}
+			for _, hop := range path {
+				names = append(names, hop.Callee.Name)
+			}
+			got = append(got, strings.Join(names, ">"))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Paths(%s, %s, %d) = %v, want %v", tt.from, tt.to, tt.maxDepth, got, tt.want)
+		}
+	}
+}
+
+func TestFuncNotFound(t *testing.T) {
+	g := newTestGraph(t)
+	if _, err := g.Callees(FuncID{Pkg: testPkg, Key: "missing"}, 0); err == nil {
+		t.Error("Callees of missing func: want error")
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"ast-callgraph/query"
+	"ast-callgraph/schema"
+	"ast-callgraph/service"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// hopOutput 一跳调用的输出格式
+type hopOutput struct {
+	Caller    string             `json:"caller"`
+	Callee    string             `json:"callee"`
+	Dynamic   bool               `json:"dynamic"`
+	CallSites []*schema.Position `json:"callSites"`
+}
+
+// queryResultOutput 查询结果的输出格式
+type queryResultOutput struct {
+	Func  string       `json:"func"`
+	Depth int          `json:"depth"`
+	Path  []*hopOutput `json:"path"`
+}
+
+func runQuery(ctx context.Context, args []string) error {
+	fs, flags := newFlagSet("query")
+	callers := fs.String("callers", "", "list callers of the func, pkg/path.Func or pkg/path.Type.Method")
+	callees := fs.String("callees", "", "list callees of the func")
+	depth := fs.Int("depth", 1, "max hops for -callers/-callees and max path length for -from/-to, 0 means unlimited")
+	from := fs.String("from", "", "list all simple paths from this func, used with -to")
+	to := fs.String("to", "", "list all simple paths to this func, used with -from")
+	var entries patternsFlag
+	fs.Var(&entries, "entry", "list funcs reachable from the entrypoint (repeatable or comma separated)")
+	if err := flags.parse(fs, args); err != nil {
+		return err
+	}
+	modes := 0
+	for _, set := range []bool{*callers != "", *callees != "", *from != "" || *to != "", len(entries) > 0} {
+		if set {
+			modes++
+		}
+	}
+	if modes != 1 {
+		return errors.New("exactly one of -callers, -callees, -from/-to or -entry is required")
+	}
+	info, err := flags.transverse(ctx)
+	if err != nil {
+		return err
+	}
+	graph := query.NewGraph(info)
+	modDir := schema.ModuleDir(info)
+	if *from != "" || *to != "" {
+		fromID, err := query.ResolveFuncID(info, *from)
+		if err != nil {
+			return err
+		}
+		toID, err := query.ResolveFuncID(info, *to)
+		if err != nil {
+			return err
+		}
+		paths, err := graph.Paths(fromID, toID, *depth)
+		if err != nil {
+			return err
+		}
+		outputs := make([][]*hopOutput, 0, len(paths))
+		for _, path := range paths {
+			outputs = append(outputs, newHopOutputs(path, modDir))
+		}
+		if flags.format == formatJson {
+			return writeJson(os.Stdout, outputs)
+		}
+		for i, path := range outputs {
+			fmt.Printf("path %d:\n", i+1)
+			printHops(path)
+		}
+		return nil
+	}
+	var results []*query.Result
+	switch {
+	case *callers != "":
+		results, err = queryByID(info, *callers, func(id query.FuncID) ([]*query.Result, error) {
+			return graph.Callers(id, *depth)
+		})
+	case *callees != "":
+		results, err = queryByID(info, *callees, func(id query.FuncID) ([]*query.Result, error) {
+			return graph.Callees(id, *depth)
+		})
+	default:
+		ids := make([]query.FuncID, 0, len(entries))
+		for _, entry := range entries {
+			id, parseErr := query.ResolveFuncID(info, entry)
+			if parseErr != nil {
+				return parseErr
+			}
+			ids = append(ids, id)
+		}
+		results, err = graph.Reachable(ids)
+	}
+	if err != nil {
+		return err
+	}
+	outputs := make([]*queryResultOutput, 0, len(results))
+	for _, result := range results {
+		outputs = append(outputs, &queryResultOutput{
+			Func:  query.IDOf(result.Func).String(),
+			Depth: result.Depth,
+			Path:  newHopOutputs(result.Path, modDir),
+		})
+	}
+	if flags.format == formatJson {
+		return writeJson(os.Stdout, outputs)
+	}
+	for _, output := range outputs {
+		fmt.Printf("%d\t%s\n", output.Depth, output.Func)
+		printHops(output.Path)
+	}
+	return nil
+}
+
+func queryByID(info *service.AstTransverseInfo, rawID string, fn func(id query.FuncID) ([]*query.Result, error)) ([]*query.Result, error) {
+	id, err := query.ResolveFuncID(info, rawID)
+	if err != nil {
+		return nil, err
+	}
+	return fn(id)
+}
+
+func newHopOutputs(hops []*query.Hop, modDir string) []*hopOutput {
+	outputs := make([]*hopOutput, 0, len(hops))
+	for _, hop := range hops {
+		output := &hopOutput{
+			Caller:    query.IDOf(hop.Caller).String(),
+			Callee:    query.IDOf(hop.Callee).String(),
+			Dynamic:   hop.Dynamic,
+			CallSites: make([]*schema.Position, 0, len(hop.CallSites)),
+		}
+		for _, site := range hop.CallSites {
+			output.CallSites = append(output.CallSites, schema.FromPosition(site.Begin, modDir))
+		}
+		outputs = append(outputs, output)
+	}
+	return outputs
+}
+
+func printHops(hops []*hopOutput) {
+	for _, hop := range hops {
+		sites := make([]string, 0, len(hop.CallSites))
+		for _, site := range hop.CallSites {
+			sites = append(sites, fmt.Sprintf("%s:%d:%d", site.File, site.Line, site.Column))
+		}
+		kind := ""
+		if hop.Dynamic {
+			kind = " (dynamic)"
+		}
+		fmt.Printf("\t%s -> %s%s\t%s\n", hop.Caller, hop.Callee, kind, strings.Join(sites, ", "))
+	}
+}
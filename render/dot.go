@@ -0,0 +1,40 @@
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// DOT 输出 Graphviz 格式，模块内节点按包聚类为 cluster
+func DOT(w io.Writer, graph *Graph) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph G {")
+	fmt.Fprintln(bw, "  rankdir=LR;")
+	fmt.Fprintln(bw, "  node [shape=box, fontname=\"Helvetica\"];")
+	for i, pkg := range graph.sortedPkgs() {
+		nodes := graph.pkgNodes(pkg)
+		if nodes[0].External {
+			for _, node := range nodes {
+				fmt.Fprintf(bw, "  %s [label=%s, style=dashed];\n", node.ID, strconv.Quote(node.Label))
+			}
+			continue
+		}
+		fmt.Fprintf(bw, "  subgraph cluster_%d {\n", i)
+		fmt.Fprintf(bw, "    label=%s;\n", strconv.Quote(pkg))
+		for _, node := range nodes {
+			fmt.Fprintf(bw, "    %s [label=%s];\n", node.ID, strconv.Quote(node.Label))
+		}
+		fmt.Fprintln(bw, "  }")
+	}
+	for _, edge := range graph.Edges {
+		if edge.Dynamic {
+			fmt.Fprintf(bw, "  %s -> %s [style=dashed];\n", edge.From, edge.To)
+		} else {
+			fmt.Fprintf(bw, "  %s -> %s;\n", edge.From, edge.To)
+		}
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
@@ -0,0 +1,256 @@
+package render
+
+import (
+	"ast-callgraph/service"
+	"ast-callgraph/vs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Options 渲染选项
+type Options struct {
+	// Root 根节点，调用图为 pkg.Key(如 ast-callgraph/service.TransverseDirectory)，结构体图为 pkg.Name，为空时渲染全部
+	Root string
+	// MaxDepth 从根节点出发的最大深度，<=0 不限制
+	MaxDepth int
+	// CollapseExternal 模块外的包折叠为一个节点
+	CollapseExternal bool
+	// HideStdlib 隐藏标准库节点
+	HideStdlib bool
+}
+
+// Graph 渲染用的有向图，节点和边均已排序
+type Graph struct {
+	Nodes []*Node
+	Edges []*Edge
+}
+
+// Node 图节点，Pkg 用于按包聚类
+type Node struct {
+	ID       string
+	Label    string
+	Pkg      string
+	External bool
+}
+
+// Edge 图的边，Dynamic 为接口动态分派的调用
+type Edge struct {
+	From    string
+	To      string
+	Dynamic bool
+}
+
+// graphBuilder 以完整名称为键收集节点和边，最终再裁剪、排序并分配稳定的节点ID
+type graphBuilder struct {
+	info    *service.AstTransverseInfo
+	options *Options
+	nodes   map[string]*Node
+	edges   map[string]map[string]*Edge
+}
+
+func newGraphBuilder(info *service.AstTransverseInfo, options *Options) *graphBuilder {
+	if options == nil {
+		options = &Options{}
+	}
+	return &graphBuilder{
+		info:    info,
+		options: options,
+		nodes:   make(map[string]*Node),
+		edges:   make(map[string]map[string]*Edge),
+	}
+}
+
+// CallGraph 构造函数调用图：模块内的节点只来自已解析的调用边(含接口展开的动态边)，未解析的模块外调用作为外部节点；
+// 函数类型参数、局部闭包变量等无法解析的模块内调用不绘制。语法推断模式只记录模块内的调用，外部节点需要类型检查模式
+func CallGraph(info *service.AstTransverseInfo, options *Options) *Graph {
+	builder := newGraphBuilder(info, options)
+	for _, goFunc := range info.SortedFuncs() {
+		from := builder.addNode(goFunc.Pkg, goFunc.Key())
+		if from == "" {
+			continue
+		}
+		for _, edge := range info.Callees(goFunc.Pkg, goFunc.Key()) {
+			if to := builder.addNode(edge.Callee.Pkg, edge.Callee.Key()); to != "" {
+				builder.addEdge(from, to, edge.Dynamic)
+			}
+		}
+		for _, calleeInfo := range goFunc.CalleeInfos {
+			pkg, key := calleeInfo.Pkg, calleeInfo.Name
+			if calleeInfo.Receiver != nil {
+				pkg, _ = vs.SplitTypeName(*calleeInfo.Receiver)
+				key = vs.FuncKey(*calleeInfo.Receiver, calleeInfo.Name)
+			}
+			if pkg == "" || info.IsModulePkg(pkg) {
+				continue
+			}
+			if to := builder.addNode(pkg, key); to != "" {
+				builder.addEdge(from, to, calleeInfo.Dynamic)
+			}
+		}
+	}
+	return builder.build()
+}
+
+// StructGraph 构造结构体依赖图
+func StructGraph(info *service.AstTransverseInfo, options *Options) *Graph {
+	builder := newGraphBuilder(info, options)
+	pkgs := make([]string, 0, len(info.StructInfoMap))
+	for pkg := range info.StructInfoMap {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+	for _, pkg := range pkgs {
+		for _, structInfo := range info.StructInfoMap[pkg] {
+			from := builder.addNode(structInfo.Pkg, structInfo.Name)
+			if from == "" {
+				continue
+			}
+			for _, indexes := range structInfo.DepsStructInfo {
+				for _, index := range indexes {
+					if to := builder.addNode(index.Pkg, index.Name); to != "" {
+						builder.addEdge(from, to, false)
+					}
+				}
+			}
+		}
+	}
+	return builder.build()
+}
+
+// addNode 添加节点并返回节点名，被隐藏时返回空
+func (b *graphBuilder) addNode(pkg string, name string) string {
+	external := !b.info.IsModulePkg(pkg)
+	if external && b.options.HideStdlib && isStdlibPkg(pkg) {
+		return ""
+	}
+	fullName := pkg + "." + name
+	label := name
+	if external && b.options.CollapseExternal {
+		fullName, label = pkg, pkg
+	}
+	if _, ok := b.nodes[fullName]; !ok {
+		b.nodes[fullName] = &Node{
+			Label:    label,
+			Pkg:      pkg,
+			External: external,
+		}
+	}
+	return fullName
+}
+
+func (b *graphBuilder) addEdge(from string, to string, dynamic bool) {
+	if _, ok := b.edges[from]; !ok {
+		b.edges[from] = make(map[string]*Edge)
+	}
+	// 同一对节点既有静态调用又有动态调用时按静态调用展示
+	if edge, ok := b.edges[from][to]; ok {
+		edge.Dynamic = edge.Dynamic && dynamic
+		return
+	}
+	b.edges[from][to] = &Edge{
+		From:    from,
+		To:      to,
+		Dynamic: dynamic,
+	}
+}
+
+// build 按根节点和深度裁剪，排序后分配 n0、n1... 形式的节点ID
+func (b *graphBuilder) build() *Graph {
+	keep := b.reachable()
+	names := make([]string, 0, len(b.nodes))
+	for name := range b.nodes {
+		if keep == nil || keep[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	ids := make(map[string]string, len(names))
+	graph := &Graph{
+		Nodes: make([]*Node, 0, len(names)),
+		Edges: make([]*Edge, 0),
+	}
+	for i, name := range names {
+		node := b.nodes[name]
+		node.ID = "n" + strconv.Itoa(i)
+		ids[name] = node.ID
+		graph.Nodes = append(graph.Nodes, node)
+	}
+	for _, from := range names {
+		tos := make([]string, 0, len(b.edges[from]))
+		for to := range b.edges[from] {
+			if _, ok := ids[to]; ok {
+				tos = append(tos, to)
+			}
+		}
+		sort.Strings(tos)
+		for _, to := range tos {
+			edge := b.edges[from][to]
+			graph.Edges = append(graph.Edges, &Edge{
+				From:    ids[from],
+				To:      ids[to],
+				Dynamic: edge.Dynamic,
+			})
+		}
+	}
+	return graph
+}
+
+// reachable 从根节点广度优先遍历到最大深度，未指定根节点时返回nil表示保留全部
+func (b *graphBuilder) reachable() map[string]bool {
+	if b.options.Root == "" {
+		return nil
+	}
+	keep := map[string]bool{b.options.Root: true}
+	frontier := []string{b.options.Root}
+	for depth := 0; len(frontier) > 0; depth++ {
+		if b.options.MaxDepth > 0 && depth >= b.options.MaxDepth {
+			break
+		}
+		next := make([]string, 0)
+		for _, from := range frontier {
+			for to := range b.edges[from] {
+				if !keep[to] {
+					keep[to] = true
+					next = append(next, to)
+				}
+			}
+		}
+		frontier = next
+	}
+	return keep
+}
+
+// sortedPkgs 节点涉及的包，按包名排序
+func (g *Graph) sortedPkgs() []string {
+	pkgSet := make(map[string]struct{})
+	for _, node := range g.Nodes {
+		pkgSet[node.Pkg] = struct{}{}
+	}
+	pkgs := make([]string, 0, len(pkgSet))
+	for pkg := range pkgSet {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+	return pkgs
+}
+
+// isStdlibPkg 标准库包路径首段不含"."
+func isStdlibPkg(pkg string) bool {
+	first := pkg
+	if idx := strings.Index(pkg, "/"); idx >= 0 {
+		first = pkg[:idx]
+	}
+	return !strings.Contains(first, ".")
+}
+
+// pkgNodes 包内节点，保持排序
+func (g *Graph) pkgNodes(pkg string) []*Node {
+	nodes := make([]*Node, 0)
+	for _, node := range g.Nodes {
+		if node.Pkg == pkg {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
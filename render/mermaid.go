@@ -0,0 +1,44 @@
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Mermaid 输出 Mermaid flowchart，模块内节点按包聚类为 subgraph
+func Mermaid(w io.Writer, graph *Graph) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "flowchart LR")
+	for i, pkg := range graph.sortedPkgs() {
+		nodes := graph.pkgNodes(pkg)
+		if nodes[0].External {
+			for _, node := range nodes {
+				fmt.Fprintf(bw, "  %s([%s])\n", node.ID, mermaidLabel(node.Label))
+			}
+			continue
+		}
+		fmt.Fprintf(bw, "  subgraph p%d[%s]\n", i, mermaidLabel(pkg))
+		for _, node := range nodes {
+			fmt.Fprintf(bw, "    %s[%s]\n", node.ID, mermaidLabel(node.Label))
+		}
+		fmt.Fprintln(bw, "  end")
+	}
+	for _, edge := range graph.Edges {
+		if edge.Dynamic {
+			fmt.Fprintf(bw, "  %s -.-> %s\n", edge.From, edge.To)
+		} else {
+			fmt.Fprintf(bw, "  %s --> %s\n", edge.From, edge.To)
+		}
+	}
+	return bw.Flush()
+}
+
+// mermaidLabelReplacer # 是 Mermaid 实体的起始字符，init#<文件名> 中的 # 与双引号一并转义，单次替换不会重复转义生成的实体
+var mermaidLabelReplacer = strings.NewReplacer("#", "#35;", "\"", "#quot;")
+
+// mermaidLabel 标签统一加引号，# 和双引号替换为实体
+func mermaidLabel(label string) string {
+	return "\"" + mermaidLabelReplacer.Replace(label) + "\""
+}
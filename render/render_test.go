@@ -0,0 +1,116 @@
+package render
+
+import (
+	"ast-callgraph/service"
+	"ast-callgraph/service/servicetest"
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+// renderSource 覆盖指针接收者方法 (*T).Greet、init#<文件名>:<行号>、包级变量初始化 init#<文件名>、匿名函数、接口动态调用和模块外调用
+var renderSource = map[string]string{
+	"main.go": `package main
+
+import "fmt"
+
+type Greeter interface{ Greet() }
+
+type T struct {
+	name  string
+	inner *Inner
+}
+
+type Inner struct{}
+
+func (t *T) Greet() { fmt.Println(t.name) }
+
+var defaultT = newT()
+
+func newT() *T { return &T{name: "t"} }
+
+func init() { defaultT.Greet() }
+
+func main() {
+	var g Greeter = defaultT
+	run(func() { g.Greet() })
+}
+
+func run(fn func()) { fn() }
+`,
+}
+
+func TestRenderGolden(t *testing.T) {
+	tests := []struct {
+		golden string
+		graph  func(info *service.AstTransverseInfo) *Graph
+		render func(w io.Writer, graph *Graph) error
+	}{
+		{golden: "call.dot", graph: callGraph, render: DOT},
+		{golden: "call.mmd", graph: callGraph, render: Mermaid},
+		{golden: "struct.dot", graph: structGraph, render: DOT},
+		{golden: "struct.mmd", graph: structGraph, render: Mermaid},
+	}
+	// 两次独立分析的输出逐字节一致
+	infos := []*service.AstTransverseInfo{
+		servicetest.Analyze(t, renderSource, &service.AstTransverseParam{TypeCheck: true}),
+		servicetest.Analyze(t, renderSource, &service.AstTransverseParam{TypeCheck: true}),
+	}
+	for _, tt := range tests {
+		outputs := make([][]byte, 0, len(infos))
+		for _, info := range infos {
+			var buf bytes.Buffer
+			if err := tt.render(&buf, tt.graph(info)); err != nil {
+				t.Fatal(err)
+			}
+			outputs = append(outputs, buf.Bytes())
+		}
+		if !bytes.Equal(outputs[0], outputs[1]) {
+			t.Errorf("%s: output differs between runs:\n%s\n%s", tt.golden, outputs[0], outputs[1])
+		}
+		path := filepath.Join("testdata", tt.golden)
+		if *update {
+			if err := os.WriteFile(path, outputs[0], 0o644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		want, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(outputs[0], want) {
+			t.Errorf("%s mismatch, rerun with -update if intended:\n%s", tt.golden, outputs[0])
+		}
+	}
+}
+
+func TestMermaidLabel(t *testing.T) {
+	tests := []struct {
+		label string
+		want  string
+	}{
+		{label: "(*T).Greet", want: `"(*T).Greet"`},
+		{label: "init#main.go:21", want: `"init#35;main.go:21"`},
+		{label: `say "hi"`, want: `"say #quot;hi#quot;"`},
+		{label: "#quot;", want: `"#35;quot;"`},
+	}
+	for _, tt := range tests {
+		if got := mermaidLabel(tt.label); got != tt.want {
+			t.Errorf("mermaidLabel(%q) = %s, want %s", tt.label, got, tt.want)
+		}
+	}
+}
+
+func callGraph(info *service.AstTransverseInfo) *Graph {
+	return CallGraph(info, nil)
+}
+
+func structGraph(info *service.AstTransverseInfo) *Graph {
+	return StructGraph(info, nil)
+}
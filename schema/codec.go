@@ -0,0 +1,651 @@
+package schema
+
+import (
+	"ast-callgraph/service"
+	"ast-callgraph/vs"
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"io"
+	"path/filepath"
+	"sort"
+)
+
+// Encode 将分析结果按当前 schema 写出
+func Encode(w io.Writer, info *service.AstTransverseInfo) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(FromInfo(info))
+}
+
+// Decode 读取导出文档并还原为分析结果，调用边重新解析
+func Decode(r io.Reader) (*service.AstTransverseInfo, error) {
+	doc := &Document{}
+	if err := json.NewDecoder(r).Decode(doc); err != nil {
+		return nil, err
+	}
+	return doc.ToInfo()
+}
+
+// FromInfo 分析结果转换为导出文档
+func FromInfo(info *service.AstTransverseInfo) *Document {
+	modDir := ModuleDir(info)
+	doc := &Document{
+		SchemaVersion: Version,
+		RootDir:       info.RootDir,
+		Packages:      make([]*Package, 0),
+	}
+	if info.ModFileInfo != nil {
+		doc.Module = fromModFileInfo(info.ModFileInfo)
+	}
+	if info.Work != nil {
+		doc.Work = &Work{
+			GoWorkPath: info.Work.WorkPath,
+			GoVersion:  info.Work.GoVersion,
+			Uses:       info.Work.Uses,
+			Replaces:   fromReplaceMods(info.Work.Replaces),
+		}
+	}
+	if len(info.Modules) > 1 || info.Work != nil {
+		for _, m := range info.Modules {
+			doc.Modules = append(doc.Modules, fromModFileInfo(m))
+		}
+	}
+	for _, diagnostic := range info.Diagnostics {
+		doc.Diagnostics = append(doc.Diagnostics, &Diagnostic{
+			Pos: &Position{
+				File:   filepath.ToSlash(diagnostic.Path),
+				Line:   diagnostic.Pos.Line,
+				Column: diagnostic.Pos.Column,
+			},
+			Message:  diagnostic.Message,
+			Severity: string(diagnostic.Severity),
+		})
+	}
+	pkgSet := make(map[string]*Package)
+	pkgOf := func(path string, name string) *Package {
+		if pkg, ok := pkgSet[path]; ok {
+			if pkg.Name == "" {
+				pkg.Name = name
+			}
+			return pkg
+		}
+		pkg := &Package{
+			Path:       path,
+			Name:       name,
+			Structs:    make([]*Struct, 0),
+			Interfaces: make([]*Interface, 0),
+			Funcs:      make([]*Func, 0),
+		}
+		pkgSet[path] = pkg
+		doc.Packages = append(doc.Packages, pkg)
+		return pkg
+	}
+	for pkgPath, structInfos := range info.StructInfoMap {
+		pkg := pkgOf(pkgPath, "")
+		for _, structInfo := range structInfos {
+			pkgOf(pkgPath, structInfo.PkgName)
+			pkg.Structs = append(pkg.Structs, FromStructInfo(structInfo))
+		}
+		sort.SliceStable(pkg.Structs, func(i, j int) bool {
+			if pkg.Structs[i].File != pkg.Structs[j].File {
+				return pkg.Structs[i].File < pkg.Structs[j].File
+			}
+			return pkg.Structs[i].StartLine < pkg.Structs[j].StartLine
+		})
+	}
+	for pkgPath, interfaceInfos := range info.InterfaceInfoMap {
+		pkg := pkgOf(pkgPath, "")
+		for _, interfaceInfo := range interfaceInfos {
+			pkgOf(pkgPath, interfaceInfo.PkgName)
+			pkg.Interfaces = append(pkg.Interfaces, FromInterfaceInfo(interfaceInfo, info.Implements[interfaceInfo.TypeName]))
+		}
+		sort.SliceStable(pkg.Interfaces, func(i, j int) bool {
+			if pkg.Interfaces[i].File != pkg.Interfaces[j].File {
+				return pkg.Interfaces[i].File < pkg.Interfaces[j].File
+			}
+			return pkg.Interfaces[i].StartLine < pkg.Interfaces[j].StartLine
+		})
+	}
+	for _, goFunc := range info.SortedFuncs() {
+		pkg := pkgOf(goFunc.Pkg, goFunc.PkgName)
+		pkg.Funcs = append(pkg.Funcs, FromGoFunc(goFunc, modDir))
+	}
+	sort.Slice(doc.Packages, func(i, j int) bool {
+		return doc.Packages[i].Path < doc.Packages[j].Path
+	})
+	return doc
+}
+
+func fromModFileInfo(m *service.ModFileInfo) *Module {
+	module := &Module{
+		Path:      m.RootPkg,
+		GoModPath: m.ModPath,
+		GoVersion: m.GoVersion,
+		Toolchain: m.Toolchain,
+		Deps:      make([]*DepsMod, 0, len(m.DepsMods)),
+		Replaces:  fromReplaceMods(m.Replaces),
+	}
+	for _, dep := range m.DepsMods {
+		module.Deps = append(module.Deps, &DepsMod{
+			Path:     dep.Pkg,
+			Version:  dep.Tag,
+			Indirect: dep.Indirect,
+		})
+	}
+	for _, exclude := range m.Excludes {
+		module.Excludes = append(module.Excludes, &Exclude{
+			Path:    exclude.Pkg,
+			Version: exclude.Tag,
+		})
+	}
+	for _, retract := range m.Retracts {
+		module.Retracts = append(module.Retracts, &RetractRange{
+			Low:       retract.Low,
+			High:      retract.High,
+			Rationale: retract.Rationale,
+		})
+	}
+	return module
+}
+
+func (m *Module) toModFileInfo() *service.ModFileInfo {
+	info := &service.ModFileInfo{
+		RootPkg:   m.Path,
+		ModPath:   m.GoModPath,
+		GoVersion: m.GoVersion,
+		Toolchain: m.Toolchain,
+		DepsMods:  make([]*service.DepsMod, 0, len(m.Deps)),
+		Replaces:  toReplaceMods(m.Replaces),
+		Excludes:  make([]*service.ExcludeMod, 0, len(m.Excludes)),
+		Retracts:  make([]*service.RetractRange, 0, len(m.Retracts)),
+	}
+	for _, dep := range m.Deps {
+		info.DepsMods = append(info.DepsMods, &service.DepsMod{
+			Pkg:      dep.Path,
+			Tag:      dep.Version,
+			Indirect: dep.Indirect,
+		})
+	}
+	for _, exclude := range m.Excludes {
+		info.Excludes = append(info.Excludes, &service.ExcludeMod{
+			Pkg: exclude.Path,
+			Tag: exclude.Version,
+		})
+	}
+	for _, retract := range m.Retracts {
+		info.Retracts = append(info.Retracts, &service.RetractRange{
+			Low:       retract.Low,
+			High:      retract.High,
+			Rationale: retract.Rationale,
+		})
+	}
+	return info
+}
+
+func fromReplaceMods(replaces []*service.ReplaceMod) []*Replace {
+	result := make([]*Replace, 0, len(replaces))
+	for _, replace := range replaces {
+		result = append(result, &Replace{
+			OldPath:    replace.OldPkg,
+			OldVersion: replace.OldTag,
+			NewPath:    replace.NewPkg,
+			NewVersion: replace.NewTag,
+			Dir:        replace.Dir,
+		})
+	}
+	return result
+}
+
+func toReplaceMods(replaces []*Replace) []*service.ReplaceMod {
+	result := make([]*service.ReplaceMod, 0, len(replaces))
+	for _, replace := range replaces {
+		result = append(result, &service.ReplaceMod{
+			OldPkg: replace.OldPath,
+			OldTag: replace.OldVersion,
+			NewPkg: replace.NewPath,
+			NewTag: replace.NewVersion,
+			Dir:    replace.Dir,
+		})
+	}
+	return result
+}
+
+// ModuleDir 文件相对路径的基准目录(工作区或主模块根目录)的绝对路径，未知时返回空
+func ModuleDir(info *service.AstTransverseInfo) string {
+	if info.RootDir != "" {
+		return info.RootDir
+	}
+	if info.ModFileInfo == nil || info.ModPath == "" {
+		return ""
+	}
+	dir, err := filepath.Abs(filepath.Dir(info.ModPath))
+	if err != nil {
+		return ""
+	}
+	return dir
+}
+
+// ToInfo 导出文档还原为分析结果
+func (d *Document) ToInfo() (*service.AstTransverseInfo, error) {
+	if d.SchemaVersion != Version {
+		return nil, fmt.Errorf("unsupported schema version %d, expect %d", d.SchemaVersion, Version)
+	}
+	info := &service.AstTransverseInfo{
+		StructInfoMap:    make(map[string][]*vs.StructInfo),
+		InterfaceInfoMap: make(map[string][]*vs.InterfaceInfo),
+		FuncInfoMap:      make(map[string]map[string]*vs.GoFunc),
+	}
+	info.RootDir = d.RootDir
+	if d.Module != nil {
+		info.RootPkg = d.Module.Path
+		info.ModFileInfo = d.Module.toModFileInfo()
+		info.Modules = []*service.ModFileInfo{info.ModFileInfo}
+	}
+	if d.Work != nil {
+		info.Work = &service.WorkFileInfo{
+			WorkPath:  d.Work.GoWorkPath,
+			GoVersion: d.Work.GoVersion,
+			Uses:      d.Work.Uses,
+			Replaces:  toReplaceMods(d.Work.Replaces),
+		}
+	}
+	if len(d.Modules) > 0 {
+		info.Modules = make([]*service.ModFileInfo, 0, len(d.Modules))
+		for _, m := range d.Modules {
+			info.Modules = append(info.Modules, m.toModFileInfo())
+		}
+		info.ModFileInfo = info.Modules[0]
+	}
+	for _, diagnostic := range d.Diagnostics {
+		pos := diagnostic.Pos.toPosition()
+		info.Diagnostics = append(info.Diagnostics, &service.Diagnostic{
+			Path:     pos.Filename,
+			Pos:      pos,
+			Message:  diagnostic.Message,
+			Severity: service.Severity(diagnostic.Severity),
+		})
+	}
+	for _, pkg := range d.Packages {
+		for _, s := range pkg.Structs {
+			structInfo := s.toStructInfo()
+			structInfo.PkgName = pkg.Name
+			info.StructInfoMap[pkg.Path] = append(info.StructInfoMap[pkg.Path], structInfo)
+		}
+		for _, i := range pkg.Interfaces {
+			interfaceInfo := i.toInterfaceInfo()
+			interfaceInfo.PkgName = pkg.Name
+			info.InterfaceInfoMap[pkg.Path] = append(info.InterfaceInfoMap[pkg.Path], interfaceInfo)
+		}
+		for _, fn := range pkg.Funcs {
+			if _, ok := info.FuncInfoMap[pkg.Path]; !ok {
+				info.FuncInfoMap[pkg.Path] = make(map[string]*vs.GoFunc)
+			}
+			goFunc := fn.toGoFunc()
+			goFunc.PkgName = pkg.Name
+			info.FuncInfoMap[pkg.Path][goFunc.Key()] = goFunc
+		}
+	}
+	info.LinkMethods()
+	info.BuildImplements()
+	info.BuildCallGraph()
+	return info, nil
+}
+
+// FromStructInfo 单个结构体转换为导出格式
+func FromStructInfo(structInfo *vs.StructInfo) *Struct {
+	s := &Struct{
+		Repo:            structInfo.Repo,
+		Pkg:             structInfo.Pkg,
+		File:            structInfo.File,
+		Generated:       structInfo.Generated,
+		Name:            structInfo.Name,
+		TypeName:        structInfo.TypeName,
+		StartLine:       structInfo.StartLine,
+		EndLine:         structInfo.EndLine,
+		Content:         structInfo.Content,
+		TypeParams:      fromTypeParams(structInfo.TypeParams),
+		Fields:          make([]*Field, 0, len(structInfo.Fields)),
+		Deps:            make([]*StructIndex, 0),
+		Methods:         make([]*Method, 0, len(structInfo.Methods)),
+		PromotedFields:  make([]*PromotedField, 0, len(structInfo.PromotedFields)),
+		PromotedMethods: make([]*PromotedMethod, 0, len(structInfo.PromotedMethods)),
+	}
+	for _, method := range structInfo.Methods {
+		s.Methods = append(s.Methods, &Method{
+			Name:            method.Name,
+			Key:             method.Key,
+			PointerReceiver: method.PointerReceiver,
+		})
+	}
+	for _, field := range structInfo.Fields {
+		s.Fields = append(s.Fields, &Field{
+			Name:     field.Name,
+			Type:     field.Type,
+			Embedded: field.Embedded,
+			Tag:      field.Tag,
+			Tags:     field.Tags,
+			Doc:      field.Doc,
+			Comment:  field.Comment,
+			Pos: &Position{
+				File:   structInfo.File,
+				Line:   field.Pos.Line,
+				Column: field.Pos.Column,
+			},
+		})
+	}
+	for _, field := range structInfo.PromotedFields {
+		s.PromotedFields = append(s.PromotedFields, &PromotedField{
+			Name: field.Name,
+			Type: field.Type,
+			Via:  field.Via,
+		})
+	}
+	for _, method := range structInfo.PromotedMethods {
+		s.PromotedMethods = append(s.PromotedMethods, &PromotedMethod{
+			Name:            method.Name,
+			Pkg:             method.Pkg,
+			Key:             method.Key,
+			PointerReceiver: method.PointerReceiver,
+			Via:             method.Via,
+		})
+	}
+	for _, indexes := range structInfo.DepsStructInfo {
+		for _, index := range indexes {
+			s.Deps = append(s.Deps, &StructIndex{
+				Pkg:      index.Pkg,
+				Name:     index.Name,
+				TypeName: index.TypeName,
+				Embedded: index.Embedded,
+			})
+		}
+	}
+	sort.Slice(s.Deps, func(i, j int) bool {
+		if s.Deps[i].Pkg != s.Deps[j].Pkg {
+			return s.Deps[i].Pkg < s.Deps[j].Pkg
+		}
+		return s.Deps[i].Name < s.Deps[j].Name
+	})
+	return s
+}
+
+func (s *Struct) toStructInfo() *vs.StructInfo {
+	structInfo := &vs.StructInfo{
+		Repo:           s.Repo,
+		Pkg:            s.Pkg,
+		File:           s.File,
+		Generated:      s.Generated,
+		Name:           s.Name,
+		TypeName:       s.TypeName,
+		StartLine:      s.StartLine,
+		EndLine:        s.EndLine,
+		Content:        s.Content,
+		TypeParams:     toTypeParams(s.TypeParams),
+		Fields:         make([]*vs.Field, 0, len(s.Fields)),
+		DepsStructInfo: make(map[string]map[string]vs.StructIndex),
+	}
+	for _, field := range s.Fields {
+		structInfo.Fields = append(structInfo.Fields, &vs.Field{
+			Name:     field.Name,
+			Type:     field.Type,
+			Embedded: field.Embedded,
+			Tag:      field.Tag,
+			Tags:     field.Tags,
+			Doc:      field.Doc,
+			Comment:  field.Comment,
+			Pos:      field.Pos.toPosition(),
+		})
+	}
+	for _, dep := range s.Deps {
+		if _, ok := structInfo.DepsStructInfo[dep.Pkg]; !ok {
+			structInfo.DepsStructInfo[dep.Pkg] = make(map[string]vs.StructIndex)
+		}
+		structInfo.DepsStructInfo[dep.Pkg][dep.Name] = vs.StructIndex{
+			Pkg:      dep.Pkg,
+			Name:     dep.Name,
+			TypeName: dep.TypeName,
+			Embedded: dep.Embedded,
+		}
+	}
+	return structInfo
+}
+
+// FromInterfaceInfo 单个接口及其实现转换为导出格式
+func FromInterfaceInfo(interfaceInfo *vs.InterfaceInfo, implementations []*service.Implementation) *Interface {
+	i := &Interface{
+		Repo:            interfaceInfo.Repo,
+		Pkg:             interfaceInfo.Pkg,
+		File:            interfaceInfo.File,
+		Generated:       interfaceInfo.Generated,
+		Name:            interfaceInfo.Name,
+		TypeName:        interfaceInfo.TypeName,
+		StartLine:       interfaceInfo.StartLine,
+		EndLine:         interfaceInfo.EndLine,
+		Content:         interfaceInfo.Content,
+		TypeParams:      fromTypeParams(interfaceInfo.TypeParams),
+		Methods:         make([]*MethodSig, 0, len(interfaceInfo.Methods)),
+		Embeds:          append(make([]string, 0, len(interfaceInfo.Embeds)), interfaceInfo.Embeds...),
+		Implementations: make([]*Implementation, 0, len(implementations)),
+	}
+	for _, method := range interfaceInfo.Methods {
+		i.Methods = append(i.Methods, &MethodSig{
+			Name:      method.Name,
+			Signature: method.Signature,
+		})
+	}
+	for _, implementation := range implementations {
+		i.Implementations = append(i.Implementations, &Implementation{
+			Pkg:     implementation.Struct.Pkg,
+			Name:    implementation.Struct.Name,
+			Pointer: implementation.Pointer,
+		})
+	}
+	return i
+}
+
+func (i *Interface) toInterfaceInfo() *vs.InterfaceInfo {
+	interfaceInfo := &vs.InterfaceInfo{
+		Repo:       i.Repo,
+		Pkg:        i.Pkg,
+		File:       i.File,
+		Generated:  i.Generated,
+		Name:       i.Name,
+		TypeName:   i.TypeName,
+		StartLine:  i.StartLine,
+		EndLine:    i.EndLine,
+		Content:    i.Content,
+		TypeParams: toTypeParams(i.TypeParams),
+		Methods:    make([]*vs.MethodSig, 0, len(i.Methods)),
+		Embeds:     append(make([]string, 0, len(i.Embeds)), i.Embeds...),
+	}
+	for _, method := range i.Methods {
+		interfaceInfo.Methods = append(interfaceInfo.Methods, &vs.MethodSig{
+			Name:      method.Name,
+			Signature: method.Signature,
+		})
+	}
+	return interfaceInfo
+}
+
+// FromGoFunc 单个函数转换为导出格式，modDir 非空时位置信息转换为相对路径
+func FromGoFunc(goFunc *vs.GoFunc, modDir string) *Func {
+	fn := &Func{
+		Repo:       goFunc.Repo,
+		Pkg:        goFunc.Pkg,
+		File:       relativeFile(modDir, goFunc.File),
+		RFile:      goFunc.RFile,
+		Generated:  goFunc.Generated,
+		Name:       goFunc.Name,
+		Key:        goFunc.Key(),
+		Receiver:   fromVar(goFunc.RecvType),
+		TypeParams: fromTypeParams(goFunc.TypeParams),
+		TestKind:   string(goFunc.TestKind),
+		Params:     fromVars(goFunc.Params),
+		Results:    fromVars(goFunc.Results),
+		Begin:      FromPosition(goFunc.Begin, modDir),
+		End:        FromPosition(goFunc.End, modDir),
+		Content:    goFunc.Content,
+		Callees:    make([]*Callee, 0, len(goFunc.CalleeInfos)),
+		TypeRefs:   goFunc.TypeRefs,
+	}
+	for _, calleeInfo := range goFunc.CalleeInfos {
+		fn.Callees = append(fn.Callees, &Callee{
+			Pkg:       calleeInfo.Pkg,
+			File:      calleeInfo.File,
+			Name:      calleeInfo.Name,
+			Begin:     FromPosition(calleeInfo.Begin, modDir),
+			End:       FromPosition(calleeInfo.End, modDir),
+			Receiver:  calleeInfo.Receiver,
+			Dynamic:   calleeInfo.Dynamic,
+			Reference: calleeInfo.Reference,
+			TypeArgs:  calleeInfo.TypeArgs,
+			Selectors: calleeInfo.Selectors,
+		})
+	}
+	return fn
+}
+
+func (fn *Func) toGoFunc() *vs.GoFunc {
+	goFunc := &vs.GoFunc{
+		Repo:        fn.Repo,
+		Pkg:         fn.Pkg,
+		File:        fn.File,
+		RFile:       fn.RFile,
+		Generated:   fn.Generated,
+		Name:        fn.Name,
+		RecvType:    fn.Receiver.toVar(),
+		TypeParams:  toTypeParams(fn.TypeParams),
+		TestKind:    vs.TestKind(fn.TestKind),
+		Params:      toVars(fn.Params),
+		Results:     toVars(fn.Results),
+		Begin:       fn.Begin.toPosition(),
+		End:         fn.End.toPosition(),
+		Content:     fn.Content,
+		CalleeInfos: make([]*vs.CalleeInfo, 0, len(fn.Callees)),
+		TmpVars:     make(map[string]*vs.Var),
+		TypeRefs:    fn.TypeRefs,
+	}
+	for _, callee := range fn.Callees {
+		goFunc.CalleeInfos = append(goFunc.CalleeInfos, &vs.CalleeInfo{
+			Pkg:       callee.Pkg,
+			File:      callee.File,
+			Name:      callee.Name,
+			Begin:     callee.Begin.toPosition(),
+			End:       callee.End.toPosition(),
+			Receiver:  callee.Receiver,
+			Dynamic:   callee.Dynamic,
+			Reference: callee.Reference,
+			TypeArgs:  callee.TypeArgs,
+			Selectors: callee.Selectors,
+		})
+	}
+	return goFunc
+}
+
+func fromVar(v *vs.Var) *Var {
+	if v == nil {
+		return nil
+	}
+	return &Var{
+		Type:             v.Type,
+		Name:             v.Name,
+		Hash:             v.Hash,
+		NoName:           v.NoName,
+		IsPointer:        v.IsPointer,
+		ContextFieldName: v.ContextFieldName,
+		StartPos:         v.StartPos,
+		EndPos:           v.EndPos,
+	}
+}
+
+func (v *Var) toVar() *vs.Var {
+	if v == nil {
+		return nil
+	}
+	return &vs.Var{
+		Type:             v.Type,
+		Name:             v.Name,
+		Hash:             v.Hash,
+		NoName:           v.NoName,
+		IsPointer:        v.IsPointer,
+		ContextFieldName: v.ContextFieldName,
+		StartPos:         v.StartPos,
+		EndPos:           v.EndPos,
+	}
+}
+
+func fromVars(vars []*vs.Var) []*Var {
+	result := make([]*Var, 0, len(vars))
+	for _, v := range vars {
+		result = append(result, fromVar(v))
+	}
+	return result
+}
+
+func toVars(vars []*Var) []*vs.Var {
+	result := make([]*vs.Var, 0, len(vars))
+	for _, v := range vars {
+		result = append(result, v.toVar())
+	}
+	return result
+}
+
+func fromTypeParams(typeParams []*vs.TypeParam) []*TypeParam {
+	if len(typeParams) == 0 {
+		return nil
+	}
+	result := make([]*TypeParam, 0, len(typeParams))
+	for _, typeParam := range typeParams {
+		result = append(result, &TypeParam{
+			Name:       typeParam.Name,
+			Constraint: typeParam.Constraint,
+		})
+	}
+	return result
+}
+
+func toTypeParams(typeParams []*TypeParam) []*vs.TypeParam {
+	if len(typeParams) == 0 {
+		return nil
+	}
+	result := make([]*vs.TypeParam, 0, len(typeParams))
+	for _, typeParam := range typeParams {
+		result = append(result, &vs.TypeParam{
+			Name:       typeParam.Name,
+			Constraint: typeParam.Constraint,
+		})
+	}
+	return result
+}
+
+// FromPosition token.Position 转换为导出格式
+func FromPosition(position token.Position, modDir string) *Position {
+	return &Position{
+		File:   relativeFile(modDir, position.Filename),
+		Line:   position.Line,
+		Column: position.Column,
+	}
+}
+
+func (p *Position) toPosition() token.Position {
+	if p == nil {
+		return token.Position{}
+	}
+	return token.Position{
+		Filename: p.File,
+		Line:     p.Line,
+		Column:   p.Column,
+	}
+}
+
+// relativeFile 模块内文件转换为相对路径，保证导出结果与机器目录无关
+func relativeFile(modDir string, file string) string {
+	if modDir == "" || !filepath.IsAbs(file) {
+		return filepath.ToSlash(file)
+	}
+	rel, err := filepath.Rel(modDir, file)
+	if err != nil {
+		return filepath.ToSlash(file)
+	}
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,64 @@
+package schema
+
+import (
+	"ast-callgraph/service"
+	"ast-callgraph/service/servicetest"
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// codecSource 同一调用方多次调用同一函数(含同一行的两个调用点)，以及经接口动态分派的调用
+const codecSource = `package p
+
+type Runner interface{ Run() }
+
+type job struct{}
+
+func (job) Run() { step() }
+
+func step() {}
+
+func Main(r Runner) {
+	step()
+	step(); step()
+	r.Run()
+	register(step)
+}
+
+func register(fn func()) {}
+`
+
+func TestEncodeDecodeCallEdges(t *testing.T) {
+	for _, typeCheck := range []bool{false, true} {
+		t.Run(fmt.Sprintf("typecheck=%v", typeCheck), func(t *testing.T) {
+			info := servicetest.Analyze(t, map[string]string{"p/p.go": codecSource}, &service.AstTransverseParam{TypeCheck: typeCheck})
+			var buf bytes.Buffer
+			if err := Encode(&buf, info); err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := Decode(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, got := callEdges(info), callEdges(decoded)
+			if len(want) == 0 {
+				t.Fatal("no call edges")
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("decoded call edges = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func callEdges(info *service.AstTransverseInfo) []string {
+	edges := make([]string, 0, len(info.CallEdges))
+	for _, edge := range info.CallEdges {
+		edges = append(edges, fmt.Sprintf("%s -> %s @%d:%d dynamic=%v reference=%v",
+			edge.Caller.Key(), edge.Callee.Key(), edge.CallSite.Begin.Line, edge.CallSite.Begin.Column,
+			edge.Dynamic, edge.CallSite.Reference))
+	}
+	return edges
+}
@@ -0,0 +1,247 @@
+// Package schema 定义 AstTransverseInfo 的 JSON 导出格式
+//
+// 文档顶层带 schemaVersion，新增可选字段不升级版本，字段删除或语义变化时升级。
+// 版本 2：指针接收者方法的 key 由 Type.Method 改为 (*Type).Method，结构体增加 methods。
+// 版本 3：var.type 等类型字符串统一为带完整包路径的规范写法，如 *example.com/pkg.T、map[string][]int、func(int) error；
+// 位置的 file 由相对主模块根目录改为相对 rootDir，使用 go.work 时 rootDir 为工作区目录。
+// 所有位置信息统一为 {file, line, column}，file 为相对 rootDir 的路径，rootDir 为 go.work 所在目录或主模块根目录。
+// 包、函数按名称排序输出，保证同一份分析结果序列化结果稳定。
+package schema
+
+// Version 当前 schema 版本
+const Version = 3
+
+// Document 导出文档
+type Document struct {
+	SchemaVersion int     `json:"schemaVersion"`
+	RootDir       string  `json:"rootDir,omitempty"`
+	Module        *Module `json:"module"`
+	// Work、Modules 多模块仓库的工作区和全部模块，Modules 第一个为 Module
+	Work     *Work      `json:"work,omitempty"`
+	Modules  []*Module  `json:"modules,omitempty"`
+	Packages []*Package `json:"packages"`
+	// Diagnostics 遍历中无法读取、解析或类型检查的文件等问题，出错文件的声明基于部分 AST 分析
+	Diagnostics []*Diagnostic `json:"diagnostics,omitempty"`
+}
+
+// Diagnostic 对应 service.Diagnostic，pos.file 为出错的文件或目录，无法定位到行时 line 为 0
+type Diagnostic struct {
+	Pos      *Position `json:"pos"`
+	Message  string    `json:"message"`
+	Severity string    `json:"severity"`
+}
+
+// Work go.work 信息，uses 为模块根目录的绝对路径
+type Work struct {
+	GoWorkPath string     `json:"goWorkPath"`
+	GoVersion  string     `json:"goVersion,omitempty"`
+	Uses       []string   `json:"uses"`
+	Replaces   []*Replace `json:"replaces,omitempty"`
+}
+
+// Module go.mod 信息
+type Module struct {
+	Path      string          `json:"path"`
+	GoModPath string          `json:"goModPath"`
+	GoVersion string          `json:"goVersion,omitempty"`
+	Toolchain string          `json:"toolchain,omitempty"`
+	Deps      []*DepsMod      `json:"deps"`
+	Replaces  []*Replace      `json:"replaces,omitempty"`
+	Excludes  []*Exclude      `json:"excludes,omitempty"`
+	Retracts  []*RetractRange `json:"retracts,omitempty"`
+}
+
+// DepsMod 依赖模块
+type DepsMod struct {
+	Path     string `json:"path"`
+	Version  string `json:"version"`
+	Indirect bool   `json:"indirect,omitempty"`
+}
+
+// Replace replace 指令，oldVersion 为空时替换所有版本，dir 为本地路径替换的目标目录
+type Replace struct {
+	OldPath    string `json:"oldPath"`
+	OldVersion string `json:"oldVersion,omitempty"`
+	NewPath    string `json:"newPath"`
+	NewVersion string `json:"newVersion,omitempty"`
+	Dir        string `json:"dir,omitempty"`
+}
+
+// Exclude exclude 指令
+type Exclude struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+}
+
+// RetractRange retract 指令，单个版本时 low 与 high 相同
+type RetractRange struct {
+	Low       string `json:"low"`
+	High      string `json:"high"`
+	Rationale string `json:"rationale,omitempty"`
+}
+
+// Package 包内的结构体和函数
+type Package struct {
+	Path string `json:"path"`
+	// Name package 子句声明的包名，可能与 path 的最后一段不同
+	Name       string       `json:"name,omitempty"`
+	Structs    []*Struct    `json:"structs"`
+	Interfaces []*Interface `json:"interfaces"`
+	Funcs      []*Func      `json:"funcs"`
+}
+
+// Struct 对应 vs.StructInfo，Deps 由 DepsStructInfo 展开并排序
+type Struct struct {
+	Repo string `json:"repo"`
+	Pkg  string `json:"pkg"`
+	File string `json:"file"`
+	// Generated 声明在 // Code generated ... DO NOT EDIT. 标记的文件中
+	Generated  bool           `json:"generated,omitempty"`
+	Name       string         `json:"name"`
+	TypeName   string         `json:"typeName"`
+	StartLine  int            `json:"startLine"`
+	EndLine    int            `json:"endLine"`
+	Content    string         `json:"content"`
+	TypeParams []*TypeParam   `json:"typeParams,omitempty"`
+	Fields     []*Field       `json:"fields"`
+	Deps       []*StructIndex `json:"deps"`
+	// PromotedFields、PromotedMethods 由方法集和嵌入关系计算，读回时重新计算
+	PromotedFields  []*PromotedField  `json:"promotedFields"`
+	PromotedMethods []*PromotedMethod `json:"promotedMethods"`
+	Methods         []*Method         `json:"methods"`
+}
+
+// Field 结构体字段，pos 的文件与所属结构体相同
+type Field struct {
+	Name     string            `json:"name"`
+	Type     string            `json:"type"`
+	Embedded bool              `json:"embedded,omitempty"`
+	Tag      string            `json:"tag,omitempty"`
+	Tags     map[string]string `json:"tags,omitempty"`
+	Doc      string            `json:"doc,omitempty"`
+	Comment  string            `json:"comment,omitempty"`
+	Pos      *Position         `json:"pos"`
+}
+
+// PromotedField 经嵌入提升的字段，via 为经过的嵌入字段名
+type PromotedField struct {
+	Name string   `json:"name"`
+	Type string   `json:"type"`
+	Via  []string `json:"via"`
+}
+
+// PromotedMethod 经嵌入提升的方法，pkg 和 key 定位 Func
+type PromotedMethod struct {
+	Name            string   `json:"name"`
+	Pkg             string   `json:"pkg"`
+	Key             string   `json:"key"`
+	PointerReceiver bool     `json:"pointerReceiver"`
+	Via             []string `json:"via"`
+}
+
+// Interface 对应 vs.InterfaceInfo，Implementations 为 Implements 中该接口的实现
+type Interface struct {
+	Repo            string            `json:"repo"`
+	Pkg             string            `json:"pkg"`
+	File            string            `json:"file"`
+	Generated       bool              `json:"generated,omitempty"`
+	Name            string            `json:"name"`
+	TypeName        string            `json:"typeName"`
+	StartLine       int               `json:"startLine"`
+	EndLine         int               `json:"endLine"`
+	Content         string            `json:"content"`
+	TypeParams      []*TypeParam      `json:"typeParams,omitempty"`
+	Methods         []*MethodSig      `json:"methods"`
+	Embeds          []string          `json:"embeds"`
+	Implementations []*Implementation `json:"implementations"`
+}
+
+// MethodSig 接口方法签名
+type MethodSig struct {
+	Name      string `json:"name"`
+	Signature string `json:"signature"`
+}
+
+// Implementation 接口的实现结构体，pointer 表示只有指针类型实现
+type Implementation struct {
+	Pkg     string `json:"pkg"`
+	Name    string `json:"name"`
+	Pointer bool   `json:"pointer,omitempty"`
+}
+
+// StructIndex 结构体依赖
+type StructIndex struct {
+	Pkg      string `json:"pkg"`
+	Name     string `json:"name"`
+	TypeName string `json:"typeName"`
+	Embedded bool   `json:"embedded,omitempty"`
+}
+
+// Method 结构体方法集中的方法，Key 与 Func.Key 一致
+type Method struct {
+	Name            string `json:"name"`
+	Key             string `json:"key"`
+	PointerReceiver bool   `json:"pointerReceiver"`
+}
+
+// Func 对应 vs.GoFunc，Key 为包内唯一标识：函数名、Type.Method 或 (*Type).Method
+type Func struct {
+	Repo       string       `json:"repo"`
+	Pkg        string       `json:"pkg"`
+	File       string       `json:"file"`
+	RFile      string       `json:"rFile"`
+	Generated  bool         `json:"generated,omitempty"`
+	Name       string       `json:"name"`
+	Key        string       `json:"key"`
+	Receiver   *Var         `json:"receiver,omitempty"`
+	TypeParams []*TypeParam `json:"typeParams,omitempty"`
+	// TestKind Test、Benchmark、Fuzz 或 Example，非测试函数省略
+	TestKind string    `json:"testKind,omitempty"`
+	Params   []*Var    `json:"params"`
+	Results  []*Var    `json:"results"`
+	Begin    *Position `json:"begin"`
+	End      *Position `json:"end"`
+	Content  string    `json:"content,omitempty"`
+	Callees  []*Callee `json:"callees"`
+	// TypeRefs 对应 vs.GoFunc.TypeRefs
+	TypeRefs []string `json:"typeRefs,omitempty"`
+}
+
+// Var 对应 vs.Var
+type Var struct {
+	Type             string `json:"type"`
+	Name             string `json:"name"`
+	Hash             string `json:"hash,omitempty"`
+	NoName           bool   `json:"noName"`
+	IsPointer        bool   `json:"isPointer"`
+	ContextFieldName string `json:"contextFieldName,omitempty"`
+	StartPos         int    `json:"startPos"`
+	EndPos           int    `json:"endPos"`
+}
+
+// Callee 对应 vs.CalleeInfo
+type Callee struct {
+	Pkg       string    `json:"pkg"`
+	File      string    `json:"file"`
+	Name      string    `json:"name"`
+	Begin     *Position `json:"begin"`
+	End       *Position `json:"end"`
+	Receiver  *string   `json:"receiver,omitempty"`
+	Dynamic   bool      `json:"dynamic,omitempty"`
+	Reference bool      `json:"reference,omitempty"`
+	TypeArgs  []string  `json:"typeArgs,omitempty"`
+	Selectors []string  `json:"selectors,omitempty"`
+}
+
+// TypeParam 对应 vs.TypeParam
+type TypeParam struct {
+	Name       string `json:"name"`
+	Constraint string `json:"constraint"`
+}
+
+// Position 源码位置
+type Position struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
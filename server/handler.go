@@ -0,0 +1,205 @@
+package server
+
+import (
+	"ast-callgraph/schema"
+	"ast-callgraph/service"
+	"ast-callgraph/vs"
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+)
+
+const defaultSearchLimit = 50
+
+// packageSummary 包概要
+type packageSummary struct {
+	Path    string `json:"path"`
+	Structs int    `json:"structs"`
+	Funcs   int    `json:"funcs"`
+}
+
+// callRef 调用关系另一端的函数及调用点
+type callRef struct {
+	Pkg      string           `json:"pkg"`
+	Key      string           `json:"key"`
+	CallSite *schema.Position `json:"callSite"`
+	Dynamic  bool             `json:"dynamic"`
+}
+
+// searchResult 搜索结果，Kind 为 struct 或 func
+type searchResult struct {
+	Kind string `json:"kind"`
+	Pkg  string `json:"pkg"`
+	Name string `json:"name"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+// ListPackages GET /api/packages
+func (s *QueryServer) ListPackages(ctx context.Context, c *app.RequestContext) {
+	summaries := make(map[string]*packageSummary)
+	summaryOf := func(pkg string) *packageSummary {
+		if summary, ok := summaries[pkg]; ok {
+			return summary
+		}
+		summary := &packageSummary{Path: pkg}
+		summaries[pkg] = summary
+		return summary
+	}
+	for pkg, structInfos := range s.info.StructInfoMap {
+		summaryOf(pkg).Structs += len(structInfos)
+	}
+	for pkg, funcs := range s.info.FuncInfoMap {
+		summaryOf(pkg).Funcs += len(funcs)
+	}
+	result := make([]*packageSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		result = append(result, summary)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Path < result[j].Path
+	})
+	c.JSON(http.StatusOK, utils.H{"packages": result})
+}
+
+// GetStruct GET /api/struct?pkg=&name= 返回结构体及其依赖
+func (s *QueryServer) GetStruct(ctx context.Context, c *app.RequestContext) {
+	pkg, name := c.Query("pkg"), c.Query("name")
+	if pkg == "" || name == "" {
+		badRequest(c, "pkg and name are required")
+		return
+	}
+	for _, structInfo := range s.info.StructInfoMap[pkg] {
+		if structInfo.Name == name {
+			c.JSON(http.StatusOK, utils.H{"struct": schema.FromStructInfo(structInfo)})
+			return
+		}
+	}
+	notFound(c, "struct "+pkg+"."+name+" not found")
+}
+
+// GetInterface GET /api/interface?pkg=&name= 返回接口及其模块内实现
+func (s *QueryServer) GetInterface(ctx context.Context, c *app.RequestContext) {
+	pkg, name := c.Query("pkg"), c.Query("name")
+	if pkg == "" || name == "" {
+		badRequest(c, "pkg and name are required")
+		return
+	}
+	interfaceInfo := s.info.GetInterface(pkg, name)
+	if interfaceInfo == nil {
+		notFound(c, "interface "+pkg+"."+name+" not found")
+		return
+	}
+	c.JSON(http.StatusOK, utils.H{"interface": schema.FromInterfaceInfo(interfaceInfo, s.info.Implements[interfaceInfo.TypeName])})
+}
+
+// GetFunc GET /api/func?pkg=&key= 返回函数及其调用方、被调用方
+func (s *QueryServer) GetFunc(ctx context.Context, c *app.RequestContext) {
+	pkg, key := c.Query("pkg"), c.Query("key")
+	if pkg == "" || key == "" {
+		badRequest(c, "pkg and key are required")
+		return
+	}
+	goFunc := s.info.GetFunc(pkg, key)
+	if goFunc == nil {
+		notFound(c, "func "+pkg+"."+key+" not found")
+		return
+	}
+	callers := make([]*callRef, 0)
+	for _, edge := range s.info.Callers(pkg, key) {
+		callers = append(callers, s.newCallRef(edge.Caller, edge))
+	}
+	callees := make([]*callRef, 0)
+	for _, edge := range s.info.Callees(pkg, key) {
+		callees = append(callees, s.newCallRef(edge.Callee, edge))
+	}
+	c.JSON(http.StatusOK, utils.H{
+		"func":    schema.FromGoFunc(goFunc, s.modDir),
+		"callers": callers,
+		"callees": callees,
+	})
+}
+
+// Search GET /api/search?q=&kind=&limit= 按名称大小写不敏感的子串匹配结构体和函数
+func (s *QueryServer) Search(ctx context.Context, c *app.RequestContext) {
+	query := strings.ToLower(c.Query("q"))
+	if query == "" {
+		badRequest(c, "q is required")
+		return
+	}
+	kind := c.Query("kind")
+	if kind != "" && kind != "struct" && kind != "func" {
+		badRequest(c, "invalid kind, expect struct or func")
+		return
+	}
+	limit := defaultSearchLimit
+	if rawLimit := c.Query("limit"); rawLimit != "" {
+		n, err := strconv.Atoi(rawLimit)
+		if err != nil || n <= 0 {
+			badRequest(c, "invalid limit")
+			return
+		}
+		limit = n
+	}
+	results := make([]*searchResult, 0)
+	if kind == "" || kind == "struct" {
+		for pkg, structInfos := range s.info.StructInfoMap {
+			for _, structInfo := range structInfos {
+				if strings.Contains(strings.ToLower(structInfo.Name), query) {
+					results = append(results, &searchResult{
+						Kind: "struct",
+						Pkg:  pkg,
+						Name: structInfo.Name,
+						File: structInfo.File,
+						Line: structInfo.StartLine,
+					})
+				}
+			}
+		}
+	}
+	if kind == "" || kind == "func" {
+		for _, goFunc := range s.info.SortedFuncs() {
+			if strings.Contains(strings.ToLower(goFunc.Key()), query) {
+				results = append(results, &searchResult{
+					Kind: "func",
+					Pkg:  goFunc.Pkg,
+					Name: goFunc.Key(),
+					File: goFunc.RFile,
+					Line: goFunc.Begin.Line,
+				})
+			}
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Pkg != results[j].Pkg {
+			return results[i].Pkg < results[j].Pkg
+		}
+		return results[i].Name < results[j].Name
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	c.JSON(http.StatusOK, utils.H{"results": results})
+}
+
+func (s *QueryServer) newCallRef(goFunc *vs.GoFunc, edge *service.CallEdge) *callRef {
+	return &callRef{
+		Pkg:      goFunc.Pkg,
+		Key:      goFunc.Key(),
+		CallSite: schema.FromPosition(edge.CallSite.Begin, s.modDir),
+		Dynamic:  edge.Dynamic,
+	}
+}
+
+func badRequest(c *app.RequestContext, msg string) {
+	c.JSON(http.StatusBadRequest, utils.H{"error": msg})
+}
+
+func notFound(c *app.RequestContext, msg string) {
+	c.JSON(http.StatusNotFound, utils.H{"error": msg})
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"ast-callgraph/schema"
+	"ast-callgraph/service/servicetest"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/common/ut"
+)
+
+var handlerSource = map[string]string{
+	"p/p.go": `package p
+
+type Reader interface{ Read() }
+
+type file struct{ name string }
+
+func (f *file) Read() { helper() }
+
+func helper() {}
+
+func Open() Reader {
+	r := &file{}
+	r.Read()
+	return r
+}
+`,
+}
+
+// response 各接口响应的并集
+type response struct {
+	Error     string            `json:"error"`
+	Packages  []*packageSummary `json:"packages"`
+	Struct    *schema.Struct    `json:"struct"`
+	Interface *schema.Interface `json:"interface"`
+	Func      *schema.Func      `json:"func"`
+	Callers   []*callRef        `json:"callers"`
+	Callees   []*callRef        `json:"callees"`
+	Results   []*searchResult   `json:"results"`
+}
+
+// summary 响应中非空部分的紧凑文本
+func (r *response) summary() string {
+	parts := make([]string, 0)
+	if r.Error != "" {
+		parts = append(parts, "error "+r.Error)
+	}
+	for _, pkg := range r.Packages {
+		parts = append(parts, fmt.Sprintf("package %s structs=%d funcs=%d", pkg.Path, pkg.Structs, pkg.Funcs))
+	}
+	if r.Struct != nil {
+		parts = append(parts, "struct "+r.Struct.Name)
+	}
+	if r.Interface != nil {
+		implementations := make([]string, 0)
+		for _, implementation := range r.Interface.Implementations {
+			name := implementation.Name
+			if implementation.Pointer {
+				name = "*" + name
+			}
+			implementations = append(implementations, name)
+		}
+		parts = append(parts, fmt.Sprintf("interface %s %v", r.Interface.Name, implementations))
+	}
+	if r.Func != nil {
+		parts = append(parts, fmt.Sprintf("func %s callers %s callees %s", r.Func.Key, callRefKeys(r.Callers), callRefKeys(r.Callees)))
+	}
+	for _, result := range r.Results {
+		parts = append(parts, result.Kind+" "+result.Name)
+	}
+	return strings.Join(parts, "; ")
+}
+
+func callRefKeys(refs []*callRef) []string {
+	keys := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		keys = append(keys, fmt.Sprintf("%s@%d", ref.Key, ref.CallSite.Line))
+	}
+	return keys
+}
+
+func TestHandlers(t *testing.T) {
+	s := NewQueryServer(servicetest.Analyze(t, handlerSource, nil), "127.0.0.1:0")
+	const pkg = "example.com/m/p"
+	tests := []struct {
+		url    string
+		status int
+		want   string
+	}{
+		{url: "/api/packages", status: http.StatusOK, want: "package example.com/m/p structs=1 funcs=3"},
+		{url: "/api/struct?pkg=" + pkg + "&name=file", status: http.StatusOK, want: "struct file"},
+		{url: "/api/struct?pkg=" + pkg, status: http.StatusBadRequest, want: "error pkg and name are required"},
+		{url: "/api/struct?pkg=" + pkg + "&name=missing", status: http.StatusNotFound, want: "error struct example.com/m/p.missing not found"},
+		{url: "/api/interface?pkg=" + pkg + "&name=Reader", status: http.StatusOK, want: "interface Reader [*file]"},
+		{url: "/api/interface?pkg=" + pkg + "&name=file", status: http.StatusNotFound, want: "error interface example.com/m/p.file not found"},
+		{
+			url:    "/api/func?pkg=" + pkg + "&key=" + url.QueryEscape("(*file).Read"),
+			status: http.StatusOK,
+			want:   "func (*file).Read callers [Open@13] callees [helper@7]",
+		},
+		{url: "/api/func?pkg=" + pkg, status: http.StatusBadRequest, want: "error pkg and key are required"},
+		{url: "/api/func?pkg=" + pkg + "&key=Missing", status: http.StatusNotFound, want: "error func example.com/m/p.Missing not found"},
+		{url: "/api/search?q=E", status: http.StatusOK, want: "func (*file).Read; func Open; struct file; func helper"},
+		{url: "/api/search?q=e&kind=func&limit=2", status: http.StatusOK, want: "func (*file).Read; func Open"},
+		{url: "/api/search?q=e&kind=struct", status: http.StatusOK, want: "struct file"},
+		{url: "/api/search?q=e&kind=interface", status: http.StatusBadRequest, want: "error invalid kind, expect struct or func"},
+		{url: "/api/search?q=e&limit=0", status: http.StatusBadRequest, want: "error invalid limit"},
+		{url: "/api/search", status: http.StatusBadRequest, want: "error q is required"},
+	}
+	for _, tt := range tests {
+		result := ut.PerformRequest(s.hertz.Engine, http.MethodGet, tt.url, nil).Result()
+		resp := &response{}
+		if err := json.Unmarshal(result.Body(), resp); err != nil {
+			t.Errorf("%s: %v: %s", tt.url, err, result.Body())
+			continue
+		}
+		if result.StatusCode() != tt.status || resp.summary() != tt.want {
+			t.Errorf("GET %s = %d %q, want %d %q", tt.url, result.StatusCode(), resp.summary(), tt.status, tt.want)
+		}
+	}
+}
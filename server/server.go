@@ -0,0 +1,42 @@
+package server
+
+import (
+	"ast-callgraph/schema"
+	"ast-callgraph/service"
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+)
+
+// QueryServer 常驻内存的分析结果查询服务，分析只在启动时执行一次
+type QueryServer struct {
+	info   *service.AstTransverseInfo
+	modDir string
+	hertz  *server.Hertz
+}
+
+// NewQueryServer 基于分析结果构造查询服务并注册路由
+func NewQueryServer(info *service.AstTransverseInfo, addr string) *QueryServer {
+	s := &QueryServer{
+		info:   info,
+		modDir: schema.ModuleDir(info),
+		hertz:  server.Default(server.WithHostPorts(addr)),
+	}
+	api := s.hertz.Group("/api")
+	api.GET("/packages", s.ListPackages)
+	api.GET("/struct", s.GetStruct)
+	api.GET("/interface", s.GetInterface)
+	api.GET("/func", s.GetFunc)
+	api.GET("/search", s.Search)
+	return s
+}
+
+// Spin 启动服务，阻塞至收到退出信号
+func (s *QueryServer) Spin() {
+	s.hertz.Spin()
+}
+
+// Shutdown 优雅关闭
+func (s *QueryServer) Shutdown(ctx context.Context) error {
+	return s.hertz.Shutdown(ctx)
+}
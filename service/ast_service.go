@@ -3,150 +3,591 @@ package service
 import (
 	"ast-callgraph/vs"
 	"context"
-	"errors"
 	"fmt"
 	"go/ast"
+	"go/build"
 	"go/parser"
 	"go/token"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 
 	"github.com/cloudwego/hertz/pkg/common/hlog"
-	"golang.org/x/mod/modfile"
+	"golang.org/x/sync/errgroup"
 )
 
 type AstTransverseParam struct {
 	Directory string
+	// GoModPath 为空时从 Directory 向上查找 go.mod
 	GoModPath *string
+	// GoWorkPath 为空时按 GOWORK 环境变量或从 Directory 向上查找 go.work，为 off 时不使用工作区
+	GoWorkPath string
+	// FollowReplaces 替换为本地路径的依赖模块一并分析，即使目录不在 Directory 下
+	FollowReplaces bool
+	// GOOS、GOARCH 按目标平台选择文件，为空时取环境变量或本机平台
+	GOOS   string
+	GOARCH string
+	// BuildTags 额外满足的构建标签，与 go build -tags 一致
+	BuildTags []string
+	// IncludeTests 同时分析 _test.go，外部测试包的包路径为 pkg/path_test
+	IncludeTests bool
+	// IncludeVendor 同时遍历 vendor 目录，其中的包路径为 vendor/ 之后的导入路径；类型检查模式下 vendor 只作为依赖加载
+	IncludeVendor bool
+	// SkipGenerated 跳过带有 // Code generated ... DO NOT EDIT. 标记的生成文件，不跳过时结果中标记为 Generated
+	SkipGenerated bool
+	// Includes 非空时只分析匹配的文件，匹配相对 RootDir 的路径或文件名
+	Includes []string
+	// Excludes 跳过匹配的文件，匹配的目录整体跳过
+	Excludes []string
+	// TypeCheck 加载完整类型信息精确解析调用，类型检查失败的包退化为语法推断
+	TypeCheck bool
+	// Workers 并发解析的文件数，<=0 时使用 CPU 核数
+	Workers int
+	// CacheDir 非空时按文件内容哈希缓存单文件分析结果，仅对语法推断模式生效
+	CacheDir string
+	// Strict 存在错误级别的诊断时返回错误，用于 CI；默认出错的文件记录诊断后尽量基于部分结果继续分析
+	Strict bool
 }
 
 type AstTransverseInfo struct {
 	RootPkg string
+	// ModFileInfo 主模块
 	*ModFileInfo
+	// Work 生效的 go.work，未使用工作区时为空
+	Work *WorkFileInfo
+	// Modules 分析范围内的全部模块，第一个为主模块
+	Modules []*ModFileInfo
+	// RootDir 文件相对路径(GoFunc.RFile 等)的基准目录
+	RootDir       string
 	StructInfoMap map[string][]*vs.StructInfo
+	// InterfaceInfoMap 包名 -> 接口定义
+	InterfaceInfoMap map[string][]*vs.InterfaceInfo
+	// Implements 接口完整类型名 -> 实现该接口的模块内结构体
+	Implements map[string][]*Implementation
+	// FuncInfoMap 包名 -> 函数标识(函数名、Type.Method 或 (*Type).Method) -> 函数信息
+	FuncInfoMap map[string]map[string]*vs.GoFunc
+	// CallEdges 已解析到具体函数的调用边
+	CallEdges []*CallEdge
+	// Diagnostics 遍历中无法读取、解析或类型检查的文件等问题，按路径和位置排序
+	Diagnostics []*Diagnostic
+
+	diagnostics   *diagnostics
+	callerIndex   map[*vs.GoFunc][]*CallEdge
+	calleeIndex   map[*vs.GoFunc][]*CallEdge
+	structsByType map[string]*vs.StructInfo
 }
 
 type ModFileInfo struct {
-	RootPkg  string
-	ModPath  string
-	DepsMods []*DepsMod
+	RootPkg   string
+	ModPath   string
+	GoVersion string
+	Toolchain string
+	DepsMods  []*DepsMod
+	// Replaces replace 指令，本地路径替换记录目标目录
+	Replaces []*ReplaceMod
+	// Excludes exclude 指令排除的模块版本
+	Excludes []*ExcludeMod
+	// Retracts 本模块撤回的版本区间
+	Retracts []*RetractRange
 }
 
 type DepsMod struct {
 	Pkg string
 	Tag string
+	// Indirect require 带 // indirect 注释
+	Indirect bool
+}
+
+// ReplaceMod replace 指令，OldTag 为空时替换所有版本，替换为本地路径时 NewTag 为空且 Dir 为目标目录的绝对路径
+type ReplaceMod struct {
+	OldPkg string
+	OldTag string
+	NewPkg string
+	NewTag string
+	Dir    string
+}
+
+// ExcludeMod exclude 指令
+type ExcludeMod struct {
+	Pkg string
+	Tag string
 }
 
-// TransverseDirectory 遍历指定目录
+// RetractRange retract 指令，单个版本时 Low 与 High 相同
+type RetractRange struct {
+	Low       string
+	High      string
+	Rationale string
+}
+
+// TransverseDirectory 遍历指定目录，目录下的每个文件归属于根目录包含它的最深模块
 func TransverseDirectory(ctx context.Context, param *AstTransverseParam) (*AstTransverseInfo, error) {
-	// 1.匹配go.mod文件内容
-	modFileInfo, err := ParseModFile(ctx, param)
-	if err != nil || modFileInfo == nil {
-		hlog.CtxWarnf(ctx, "TransverseDirectory ParseModFile err")
-		return nil, errors.New("invalid go mod path")
+	// 1.发现工作区和模块
+	workspace, err := DiscoverModules(ctx, param)
+	if err != nil {
+		hlog.CtxWarnf(ctx, "TransverseDirectory DiscoverModules err %v", err)
+		return nil, fmt.Errorf("invalid go mod path: %w", err)
 	}
+	modFileInfo := workspace.Main()
 	// 2.构造返回值
 	astTransverseInfo := &AstTransverseInfo{
-		RootPkg:       modFileInfo.RootPkg,
-		ModFileInfo:   modFileInfo,
-		StructInfoMap: make(map[string][]*vs.StructInfo),
+		RootPkg:          modFileInfo.RootPkg,
+		ModFileInfo:      modFileInfo,
+		Work:             workspace.Work,
+		Modules:          workspace.Modules,
+		RootDir:          workspace.RootDir(),
+		StructInfoMap:    make(map[string][]*vs.StructInfo),
+		InterfaceInfoMap: make(map[string][]*vs.InterfaceInfo),
+		FuncInfoMap:      make(map[string]map[string]*vs.GoFunc),
+		diagnostics:      newDiagnostics(workspace.RootDir()),
+	}
+	// 3.遍历文件目录下所有内容，类型检查模式加载失败时退化为语法分析
+	if param.TypeCheck {
+		if param.CacheDir != "" {
+			hlog.CtxInfof(ctx, "TransverseDirectory cache is ignored in type check mode")
+		}
+		err = transverseTypedPackages(ctx, param, workspace, astTransverseInfo)
+		if err != nil {
+			hlog.CtxWarnf(ctx, "TransverseDirectory transverseTypedPackages err %v, fallback to syntactic mode", err)
+			astTransverseInfo.StructInfoMap = make(map[string][]*vs.StructInfo)
+			astTransverseInfo.InterfaceInfoMap = make(map[string][]*vs.InterfaceInfo)
+			astTransverseInfo.FuncInfoMap = make(map[string]map[string]*vs.GoFunc)
+			astTransverseInfo.diagnostics = newDiagnostics(workspace.RootDir())
+			astTransverseInfo.diagnostics.add(param.Directory, SeverityWarning, fmt.Errorf("type check failed, fallback to syntactic mode: %w", err))
+			err = transverseFiles(ctx, param, workspace, astTransverseInfo)
+		}
+	} else {
+		err = transverseFiles(ctx, param, workspace, astTransverseInfo)
+	}
+	if err != nil {
+		hlog.CtxWarnf(ctx, "TransverseDirectory Walk err %v", err)
+		return nil, err
 	}
-	// 3.遍历文件目录下所有内容
-	if err := filepath.Walk(param.Directory, func(path string, info fs.FileInfo, err error) error {
-		// 错误是否传播
+	astTransverseInfo.Diagnostics = astTransverseInfo.diagnostics.sorted()
+	if errs := astTransverseInfo.Errors(); param.Strict && len(errs) > 0 {
+		return nil, fmt.Errorf("strict mode: %d error diagnostics, first %s", len(errs), errs[0])
+	}
+	// 4.关联方法集，计算接口实现，解析调用边
+	astTransverseInfo.LinkMethods()
+	astTransverseInfo.BuildImplements()
+	astTransverseInfo.BuildCallGraph()
+	return astTransverseInfo, nil
+}
+
+// transverseFiles 收集目录下的go文件后并发解析，仅基于语法推断调用关系
+func transverseFiles(ctx context.Context, param *AstTransverseParam, workspace *Workspace, astTransverseInfo *AstTransverseInfo) error {
+	rootDir := workspace.RootDir()
+	buildContext := param.buildContext()
+	// a.收集待分析文件及其所属模块
+	type moduleFile struct {
+		path   string
+		pkg    string
+		module *ModFileInfo
+	}
+	diagnostics := astTransverseInfo.diagnostics
+	files := make([]*moduleFile, 0)
+	root := ""
+	walk := func(path string, info fs.FileInfo, err error) error {
+		// 遍历起点无法访问时中断，其余无法访问的文件和目录记录诊断后跳过
 		if err != nil {
 			hlog.CtxWarnf(ctx, "TransverseDirectory Walk %s err %v", path, err)
+			if path == root {
+				return err
+			}
+			diagnostics.add(path, SeverityError, err)
+			if info != nil && info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
 			return err
 		}
 		// 目录是否遍历
 		if info.IsDir() {
+			if param.skipDir(rootDir, path, root) {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 		// 分析有效文件
-		if strings.HasSuffix(path, ".go") && !strings.HasSuffix(path, "_test.go") {
-			fileSet := token.NewFileSet()
-			fileContent, err := os.ReadFile(path)
+		if strings.HasSuffix(path, ".go") && (param.IncludeTests || !strings.HasSuffix(path, "_test.go")) {
+			module := workspace.ModuleOf(path)
+			if module == nil {
+				hlog.CtxDebugf(ctx, "TransverseDirectory %s is outside any module, skipped", path)
+				return nil
+			}
+			if !param.matchFile(relativePath(rootDir, path)) {
+				return nil
+			}
+			// 基于文件在所属模块内的路径分析包名
+			pkg, err := deductPkgFromPath(module, relativePath(module.Dir(), path))
 			if err != nil {
-				hlog.CtxWarnf(ctx, "TransverseDirectory ReadFile err %v", err)
+				hlog.CtxWarnf(ctx, "TransverseDirectory deductPkgFromPath err %v", err)
+				diagnostics.add(path, SeverityError, err)
+				return nil
+			}
+			files = append(files, &moduleFile{
+				path:   path,
+				pkg:    pkg,
+				module: module,
+			})
+		}
+		return nil
+	}
+	for _, root = range workspace.walkRoots(param.Directory) {
+		if err := filepath.Walk(root, walk); err != nil {
+			return err
+		}
+	}
+	// b.并发预读文件头：不满足目标平台和构建标签的文件不参与构建，否则不同平台的同名函数互相覆盖；
+	// 导入包名与目录名不同的模块内包时按声明的包名匹配；按需跳过生成文件
+	paths := make([]string, 0, len(files))
+	for _, file := range files {
+		paths = append(paths, file.path)
+	}
+	headers, err := readFileHeaders(ctx, param.workers(), buildContext, paths)
+	if err != nil {
+		return err
+	}
+	filePkgs := make(map[string]string, len(files))
+	fileNames := make(map[string]string, len(files))
+	kept := files[:0]
+	for i, file := range files {
+		header := headers[i]
+		if header.err != nil {
+			hlog.CtxWarnf(ctx, "TransverseDirectory matchBuildConstraints err %v", header.err)
+			diagnostics.add(file.path, SeverityError, header.err)
+			continue
+		}
+		name := header.name
+		if !header.match || header.generated && param.SkipGenerated {
+			continue
+		}
+		// 外部测试包与被测包同目录，与 go 命令一致包路径加 _test 后缀
+		if strings.HasSuffix(file.path, "_test.go") && strings.HasSuffix(name, "_test") {
+			file.pkg += "_test"
+		}
+		filePkgs[file.path] = file.pkg
+		fileNames[file.path] = name
+		kept = append(kept, file)
+	}
+	files = kept
+	pkgNames := collectPkgNames(filePkgs, fileNames)
+	// c.共享 FileSet 并发解析和遍历，命中缓存的文件跳过解析
+	var cache *fileCache
+	if param.CacheDir != "" {
+		c, err := newFileCache(param.CacheDir, workspace.goModPaths()...)
+		if err != nil {
+			hlog.CtxWarnf(ctx, "TransverseDirectory newFileCache err %v, cache disabled", err)
+		} else {
+			cache = c.withPkgNames(pkgNames)
+		}
+	}
+	modulePaths := workspace.ModulePaths()
+	fileSet := token.NewFileSet()
+	return astTransverseInfo.visitFiles(ctx, param.workers(), len(files), func(i int) (*vs.FileFuncVisitor, error) {
+		path, currentPkg, module := files[i].path, files[i].pkg, files[i].module
+		fileContent, err := os.ReadFile(path)
+		if err != nil {
+			hlog.CtxWarnf(ctx, "TransverseDirectory ReadFile err %v", err)
+			diagnostics.add(path, SeverityError, err)
+			return nil, nil
+		}
+		cacheKey := ""
+		if cache != nil {
+			cacheKey = cache.key(path, currentPkg, fileContent)
+			if visitor, ok := cache.load(cacheKey); ok {
+				return visitor, nil
+			}
+		}
+		// 语法错误时 ParseFile 仍返回部分 AST，基于部分结果继续分析
+		astFile, parseErr := parser.ParseFile(fileSet, path, fileContent, parser.ParseComments)
+		if parseErr != nil {
+			hlog.CtxWarnf(ctx, "TransverseDirectory ParseFile err %v", parseErr)
+			diagnostics.add(path, SeverityError, parseErr)
+			if astFile == nil || astFile.Name == nil {
+				return nil, nil
+			}
+		}
+		// 遍历节点
+		visitor := vs.NewFileFuncVisitor(module.RootPkg, currentPkg, path, relativePath(rootDir, path), fileSet, fileContent)
+		visitor.ModulePaths = modulePaths
+		visitor.PkgNames = pkgNames
+		// panic 之前已采集的声明仍然保留
+		walkErr := walkFile(visitor, astFile)
+		if walkErr != nil {
+			hlog.CtxWarnf(ctx, "TransverseDirectory walk %s err %v", path, walkErr)
+			diagnostics.add(path, SeverityError, walkErr)
+		}
+		// 部分结果不缓存，保证再次分析时仍能报告诊断
+		if cache != nil && parseErr == nil && walkErr == nil {
+			if err := cache.store(cacheKey, visitor); err != nil {
+				hlog.CtxWarnf(ctx, "TransverseDirectory cache store err %v", err)
+			}
+		}
+		return visitor, nil
+	})
+}
+
+// walkFile 遍历单个文件。访问器已能处理部分 AST 中的 BadExpr、BadDecl 和缺失的位置，recover 仅作为兜底：
+// 访问器未覆盖的情况导致的 panic 转换为错误，只影响该文件，且 panic 之前访问器已采集的结果保留
+func walkFile(visitor *vs.FileFuncVisitor, astFile *ast.File) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("analyze file panic: %v", r)
+		}
+	}()
+	ast.Walk(visitor, astFile)
+	return nil
+}
+
+// visitFiles 以有限并发访问 n 个文件，结果按输入顺序汇总，保证与串行结果一致；visit 返回空访问器的文件已记录诊断，
+// 返回错误或 ctx 取消时提前结束
+func (a *AstTransverseInfo) visitFiles(ctx context.Context, workers int, n int, visit func(i int) (*vs.FileFuncVisitor, error)) error {
+	visitors := make([]*vs.FileFuncVisitor, n)
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(workers)
+	for i := 0; i < n && groupCtx.Err() == nil; i++ {
+		group.Go(func() error {
+			if err := groupCtx.Err(); err != nil {
 				return err
 			}
-			astFile, err := parser.ParseFile(fileSet, path, fileContent, parser.ParseComments)
+			visitor, err := visit(i)
 			if err != nil {
-				hlog.CtxWarnf(ctx, "TransverseDirectory ParseFile err %v", err)
 				return err
 			}
-			// a.基于路径分析包名
-			currentPkg, err := deductPkgFromPath(modFileInfo, path)
-			if err != nil {
-				hlog.CtxWarnf(ctx, "TransverseDirectory deductPkgFromPath err %v", err)
+			visitors[i] = visitor
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for _, visitor := range visitors {
+		if visitor != nil {
+			a.collectVisitor(ctx, visitor)
+		}
+	}
+	return nil
+}
+
+// collectVisitor 汇总单文件访问结果
+func (a *AstTransverseInfo) collectVisitor(ctx context.Context, visitor *vs.FileFuncVisitor) {
+	for s, infos := range visitor.StructInfoMap {
+		a.StructInfoMap[s] = append(a.StructInfoMap[s], infos...)
+	}
+	for s, infos := range visitor.InterfaceInfoMap {
+		a.InterfaceInfoMap[s] = append(a.InterfaceInfoMap[s], infos...)
+	}
+	for _, goFunc := range visitor.FuncMap {
+		if _, ok := a.FuncInfoMap[goFunc.Pkg]; !ok {
+			a.FuncInfoMap[goFunc.Pkg] = make(map[string]*vs.GoFunc)
+		}
+		if existing, ok := a.FuncInfoMap[goFunc.Pkg][goFunc.Key()]; ok && existing.File != goFunc.File {
+			hlog.CtxWarnf(ctx, "duplicate definition of %s.%s in %s and %s, check build constraints", goFunc.Pkg, goFunc.Key(), existing.RFile, goFunc.RFile)
+			if a.diagnostics != nil {
+				a.diagnostics.addAt(goFunc.File, goFunc.Begin, fmt.Sprintf("%s redeclared, previous declaration in %s", goFunc.Key(), existing.RFile), SeverityWarning)
+			}
+		}
+		a.FuncInfoMap[goFunc.Pkg][goFunc.Key()] = goFunc
+	}
+}
+
+// deductPkgFromPath 由文件相对所属模块根目录的路径推导包路径，与声明的包名无关，模块根目录下的文件即模块路径本身，
+// vendor 下的文件为依赖包，包路径即 vendor/ 之后的导入路径
+func deductPkgFromPath(info *ModFileInfo, rFilePath string) (string, error) {
+	dir := filepath.ToSlash(filepath.Dir(rFilePath))
+	if filepath.IsAbs(rFilePath) || dir == ".." || strings.HasPrefix(dir, "../") {
+		return "", fmt.Errorf("file %s is outside module %s", rFilePath, info.RootPkg)
+	}
+	if dir == "." {
+		return info.RootPkg, nil
+	}
+	if vendorPkg, ok := strings.CutPrefix(dir, "vendor/"); ok {
+		return vendorPkg, nil
+	}
+	return info.RootPkg + "/" + dir, nil
+}
+
+// fileHeader 读取到 package 子句即可确定的文件信息，err 为构建约束无法解析等错误
+type fileHeader struct {
+	match     bool
+	name      string
+	generated bool
+	err       error
+}
+
+// readFileHeaders 以有限并发预读文件头，结果与输入顺序一致，只在 ctx 取消时返回错误
+func readFileHeaders(ctx context.Context, workers int, buildContext *build.Context, paths []string) ([]fileHeader, error) {
+	headers := make([]fileHeader, len(paths))
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(workers)
+	for i, path := range paths {
+		group.Go(func() error {
+			if err := groupCtx.Err(); err != nil {
 				return err
 			}
-			// b.遍历节点
-			visitor := &vs.FileStructVisitor{
-				RootPkg:    modFileInfo.RootPkg,
-				CurrentPkg: currentPkg,
+			header := &headers[i]
+			if header.match, header.err = matchBuildConstraints(buildContext, path); header.match && header.err == nil {
+				header.name, header.generated = readFileHeader(path)
 			}
-			ast.Walk(visitor, astFile)
-			// c.数据采集
-			for s, infos := range visitor.StructInfoMap {
-				astTransverseInfo.StructInfoMap[s] = append(astTransverseInfo.StructInfoMap[s], infos...)
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+	return headers, nil
+}
+
+// readFileHeader 文件 package 子句声明的包名以及是否为生成文件，生成标记必须位于 package 子句之前，无法解析时返回空
+func readFileHeader(path string) (string, bool) {
+	astFile, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.PackageClauseOnly|parser.ParseComments)
+	if err != nil {
+		return "", false
+	}
+	return astFile.Name.Name, ast.IsGenerated(astFile)
+}
+
+// collectPkgNames 由各文件声明的包名得到包路径 -> 包名，同一包存在多个包名时取文件数最多的
+func collectPkgNames(filePkgs map[string]string, fileNames map[string]string) map[string]string {
+	counts := make(map[string]map[string]int)
+	for path, pkg := range filePkgs {
+		name := fileNames[path]
+		if name == "" {
+			continue
+		}
+		if _, ok := counts[pkg]; !ok {
+			counts[pkg] = make(map[string]int)
+		}
+		counts[pkg][name]++
+	}
+	pkgNames := make(map[string]string, len(counts))
+	for pkg, names := range counts {
+		best := ""
+		for name, count := range names {
+			if best == "" || count > names[best] || count == names[best] && name < best {
+				best = name
 			}
 		}
-		return err
-	}); err != nil {
-		hlog.CtxWarnf(ctx, "TransverseDirectory Walk err %v", err)
-		return nil, err
+		pkgNames[pkg] = best
 	}
-	return astTransverseInfo, nil
+	return pkgNames
+}
+
+func (p *AstTransverseParam) workers() int {
+	if p.Workers <= 0 {
+		return runtime.NumCPU()
+	}
+	return p.Workers
+}
+
+// relativePath 文件相对模块根目录的路径，无法计算时返回原路径
+func relativePath(modDir string, path string) string {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	rFilePath, err := filepath.Rel(modDir, absPath)
+	if err != nil {
+		return path
+	}
+	return rFilePath
+}
+
+// skipDir 目录是否跳过：与 go 命令一致忽略 testdata、vendor 以及 . 和 _ 开头的目录，vendor 仅在 IncludeVendor 时遍历，
+// Excludes 匹配的目录整体跳过；遍历起点本身总是遍历
+func (p *AstTransverseParam) skipDir(rootDir string, path string, walkRoot string) bool {
+	if path == walkRoot {
+		return false
+	}
+	if isIgnoredDir(filepath.Base(path), p.IncludeVendor) {
+		return true
+	}
+	return matchAnyPattern(p.Excludes, relativePath(rootDir, path))
+}
+
+// inExcludedDir 文件的任一上级目录是否被 Excludes 匹配，用于类型检查模式下过滤 packages.Load 返回的文件
+func (p *AstTransverseParam) inExcludedDir(rootDir string, path string) bool {
+	for dir := filepath.Dir(relativePath(rootDir, path)); dir != "." && dir != ".." && dir != string(filepath.Separator); dir = filepath.Dir(dir) {
+		if matchAnyPattern(p.Excludes, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// isIgnoredDir go 命令展开 ./... 时忽略的目录
+func isIgnoredDir(name string, includeVendor bool) bool {
+	if name == "vendor" {
+		return !includeVendor
+	}
+	return name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")
+}
+
+// matchFile 按 Includes/Excludes 过滤文件
+func (p *AstTransverseParam) matchFile(rFilePath string) bool {
+	if len(p.Includes) > 0 && !matchAnyPattern(p.Includes, rFilePath) {
+		return false
+	}
+	return !matchAnyPattern(p.Excludes, rFilePath)
 }
 
-func deductPkgFromPath(info *ModFileInfo, filePath string) (string, error) {
-	// 获取文件目录
-	dir := filepath.Dir(filePath)
-	// 将路径分割为部分
-	parts := strings.Split(dir, string(filepath.Separator))
-	// 组合成实际包名
-	if len(parts) == 0 {
-		return "", fmt.Errorf("invalid file path")
+func matchAnyPattern(patterns []string, rFilePath string) bool {
+	slashPath := filepath.ToSlash(rFilePath)
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, slashPath); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, filepath.Base(rFilePath)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// FindGoModPath 从目录开始逐级向上查找 go.mod
+func FindGoModPath(directory string) (string, error) {
+	dir, err := filepath.Abs(directory)
+	if err != nil {
+		return "", err
+	}
+	for {
+		goModPath := filepath.Join(dir, "go.mod")
+		if info, err := os.Stat(goModPath); err == nil && !info.IsDir() {
+			return goModPath, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("go.mod not found from %s", directory)
+		}
+		dir = parent
 	}
-	// 组合包名
-	actualPackageName := info.RootPkg + "/" + strings.Join(parts, "/")
-	return actualPackageName, nil
 }
 
+// ParseModFile 解析 -gomod 指定或从 Directory 向上查找到的 go.mod
 func ParseModFile(ctx context.Context, param *AstTransverseParam) (*ModFileInfo, error) {
 	var goModPath string
 	if param.GoModPath == nil {
-		goModPath = "go.mod"
+		path, err := FindGoModPath(param.Directory)
+		if err != nil {
+			hlog.CtxWarnf(ctx, "TransverseDirectory FindGoModPath err %v", err)
+			return nil, err
+		}
+		goModPath = path
 	} else {
 		goModPath = *param.GoModPath
 	}
-	modFileContent, err := os.ReadFile(goModPath)
+	m, err := parseGoMod(goModPath)
 	if err != nil {
-		hlog.CtxWarnf(ctx, "TransverseDirectory ReadFile err %v", err)
+		hlog.CtxWarnf(ctx, "TransverseDirectory parseGoMod err %v", err)
 		return nil, err
 	}
-	// 解析 go.mod 文件
-	modFile, err := modfile.Parse("go.mod", modFileContent, nil)
-	if err != nil {
-		hlog.CtxWarnf(ctx, "TransverseDirectory Parse err %v", err)
-		return nil, err
-	}
-	if modFile != nil {
-		return nil, err
-	}
-	m := &ModFileInfo{
-		RootPkg:  modFile.Module.Mod.Path,
-		ModPath:  goModPath,
-		DepsMods: make([]*DepsMod, 0),
-	}
-	for _, require := range modFile.Require {
-		m.DepsMods = append(m.DepsMods, &DepsMod{
-			Pkg: require.Mod.Path,
-			Tag: require.Mod.Version,
-		})
-	}
 	return m, nil
 }
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"ast-callgraph/internal/testmod"
+	"context"
+	"fmt"
+	"testing"
+)
+
+// workersSource 多个包互相调用，含一个语法错误的文件，用于比较串行和并发分析的结果
+func workersSource() map[string]string {
+	files := map[string]string{
+		"main.go":    "package main\n\nimport \"example.com/m/p0\"\n\nfunc main() { p0.F0() }\n",
+		"bad/bad.go": "package bad\n\nfunc Broken( {\n",
+	}
+	const pkgCnt, fileCnt = 6, 4
+	for i := 0; i < pkgCnt; i++ {
+		for j := 0; j < fileCnt; j++ {
+			src := fmt.Sprintf("package p%d\n\n", i)
+			if i+1 < pkgCnt {
+				src += fmt.Sprintf("import \"example.com/m/p%d\"\n\n", i+1)
+			}
+			src += fmt.Sprintf("type T%d struct{ N int }\n\nfunc (t *T%d) M() { F%d() }\n\nfunc F%d() {\n", j, j, (j+1)%fileCnt, j)
+			if i+1 < pkgCnt {
+				src += fmt.Sprintf("\tp%d.F%d()\n", i+1, j)
+			}
+			src += fmt.Sprintf("\tfunc() { (&T%d{}).M() }()\n}\n", j)
+			files[fmt.Sprintf("p%d/f%d.go", i, j)] = src
+		}
+	}
+	return files
+}
+
+func TestTransverseDirectoryWorkers(t *testing.T) {
+	dir := testmod.Write(t, workersSource())
+	for _, typeCheck := range []bool{false, true} {
+		results := make(map[int]string)
+		for _, workers := range []int{1, 8} {
+			info, err := TransverseDirectory(context.Background(), &AstTransverseParam{Directory: dir, TypeCheck: typeCheck, Workers: workers})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(info.CallEdges) == 0 || len(info.Diagnostics) == 0 {
+				t.Fatalf("typecheck=%v workers=%d: edges = %d, diagnostics = %d, want both", typeCheck, workers, len(info.CallEdges), len(info.Diagnostics))
+			}
+			results[workers] = snapshot(t, info)
+		}
+		if results[1] != results[8] {
+			t.Errorf("typecheck=%v: workers=8 result\n%s\nwant workers=1 result\n%s", typeCheck, results[8], results[1])
+		}
+	}
+}
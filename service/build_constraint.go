@@ -0,0 +1,80 @@
+package service
+
+import (
+	"fmt"
+	"go/build"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Platform 目标平台 GOOS/GOARCH
+type Platform struct {
+	GOOS   string
+	GOARCH string
+}
+
+func (p Platform) String() string {
+	return p.GOOS + "/" + p.GOARCH
+}
+
+// ParsePlatform 解析 linux/amd64 形式的平台
+func ParsePlatform(s string) (Platform, error) {
+	goos, goarch, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok || goos == "" || goarch == "" {
+		return Platform{}, fmt.Errorf("invalid platform %q, expect GOOS/GOARCH", s)
+	}
+	return Platform{
+		GOOS:   goos,
+		GOARCH: goarch,
+	}, nil
+}
+
+// platform 分析的目标平台，未指定时与 go 命令一致取环境变量 GOOS/GOARCH 或本机平台
+func (p *AstTransverseParam) platform() Platform {
+	platform := Platform{
+		GOOS:   p.GOOS,
+		GOARCH: p.GOARCH,
+	}
+	if platform.GOOS == "" {
+		platform.GOOS = build.Default.GOOS
+	}
+	if platform.GOARCH == "" {
+		platform.GOARCH = build.Default.GOARCH
+	}
+	return platform
+}
+
+// buildContext 按目标平台和构建标签判断文件是否参与构建，交叉分析时与 go 命令一致默认关闭 cgo
+func (p *AstTransverseParam) buildContext() *build.Context {
+	platform := p.platform()
+	ctxt := build.Default
+	ctxt.GOOS = platform.GOOS
+	ctxt.GOARCH = platform.GOARCH
+	ctxt.BuildTags = p.BuildTags
+	ctxt.CgoEnabled = build.Default.CgoEnabled && platform.GOOS == runtime.GOOS && platform.GOARCH == runtime.GOARCH
+	return &ctxt
+}
+
+// matchBuildConstraints 文件名后缀(_linux.go、_amd64.go)和 //go:build 约束是否满足目标平台和构建标签
+func matchBuildConstraints(ctxt *build.Context, path string) (bool, error) {
+	return ctxt.MatchFile(filepath.Dir(path), filepath.Base(path))
+}
+
+// goEnv 类型检查模式下传给 go 命令的平台和 cgo 设置
+func (p *AstTransverseParam) goEnv() []string {
+	ctxt := p.buildContext()
+	cgo := "0"
+	if ctxt.CgoEnabled {
+		cgo = "1"
+	}
+	return []string{"GOOS=" + ctxt.GOOS, "GOARCH=" + ctxt.GOARCH, "CGO_ENABLED=" + cgo}
+}
+
+// buildFlags 类型检查模式下传给 go 命令的构建标签
+func (p *AstTransverseParam) buildFlags() []string {
+	if len(p.BuildTags) == 0 {
+		return nil
+	}
+	return []string{"-tags=" + strings.Join(p.BuildTags, ",")}
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"ast-callgraph/internal/testmod"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestParsePlatform(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    Platform
+		wantErr bool
+	}{
+		{s: "linux/amd64", want: Platform{GOOS: "linux", GOARCH: "amd64"}},
+		{s: " darwin/arm64 ", want: Platform{GOOS: "darwin", GOARCH: "arm64"}},
+		{s: "linux", wantErr: true},
+		{s: "/amd64", wantErr: true},
+		{s: "linux/", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePlatform(tt.s)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParsePlatform(%q) = %v, %v, want %v, wantErr %v", tt.s, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestMatchBuildConstraints(t *testing.T) {
+	dir := testmod.Write(t, map[string]string{
+		"plain.go":             "package p\n",
+		"os_linux.go":          "package p\n",
+		"os_windows.go":        "package p\n",
+		"arch_arm64.go":        "package p\n",
+		"both_linux_amd64.go":  "package p\n",
+		"both_darwin_arm64.go": "package p\n",
+		"tagged.go":            "//go:build integration\n\npackage p\n",
+		"expr.go":              "//go:build (linux || darwin) && !integration\n\npackage p\n",
+		"ignored.go":           "//go:build ignore\n\npackage p\n",
+		"legacy.go":            "// +build linux\n\npackage p\n",
+	})
+	tests := []struct {
+		name  string
+		param *AstTransverseParam
+		want  []string
+	}{
+		{
+			name:  "linux/amd64",
+			param: &AstTransverseParam{GOOS: "linux", GOARCH: "amd64"},
+			want:  []string{"both_linux_amd64.go", "expr.go", "legacy.go", "os_linux.go", "plain.go"},
+		},
+		{
+			name:  "darwin/arm64",
+			param: &AstTransverseParam{GOOS: "darwin", GOARCH: "arm64"},
+			want:  []string{"arch_arm64.go", "both_darwin_arm64.go", "expr.go", "plain.go"},
+		},
+		{
+			name:  "windows/amd64 with tags",
+			param: &AstTransverseParam{GOOS: "windows", GOARCH: "amd64", BuildTags: []string{"integration"}},
+			want:  []string{"os_windows.go", "plain.go", "tagged.go"},
+		},
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		ctxt := tt.param.buildContext()
+		got := make([]string, 0)
+		for _, path := range files {
+			match, err := matchBuildConstraints(ctxt, path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if match {
+				got = append(got, filepath.Base(path))
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: matched %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGoEnv(t *testing.T) {
+	cross := Platform{GOOS: "plan9", GOARCH: "386"}
+	if runtime.GOOS == cross.GOOS {
+		cross.GOOS = "js"
+	}
+	param := &AstTransverseParam{GOOS: cross.GOOS, GOARCH: cross.GOARCH, BuildTags: []string{"a", "b"}}
+	// 交叉分析时关闭 cgo
+	if want := []string{"GOOS=" + cross.GOOS, "GOARCH=" + cross.GOARCH, "CGO_ENABLED=0"}; !reflect.DeepEqual(param.goEnv(), want) {
+		t.Errorf("goEnv = %v, want %v", param.goEnv(), want)
+	}
+	if want := []string{"-tags=a,b"}; !reflect.DeepEqual(param.buildFlags(), want) {
+		t.Errorf("buildFlags = %v, want %v", param.buildFlags(), want)
+	}
+	if flags := (&AstTransverseParam{}).buildFlags(); flags != nil {
+		t.Errorf("buildFlags without tags = %v, want nil", flags)
+	}
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"ast-callgraph/vs"
+	"sort"
+)
+
+// CallEdge 调用边，CallSite 为调用方函数中的调用点
+type CallEdge struct {
+	Caller   *vs.GoFunc
+	Callee   *vs.GoFunc
+	CallSite *vs.CalleeInfo
+	// Dynamic 接口动态分派展开的边，区别于静态调用
+	Dynamic bool
+}
+
+// BuildCallGraph 将各函数的 CalleeInfo 解析为指向具体 GoFunc 的调用边，无法解析的调用点(外部包等)忽略；
+// 接收者为模块内接口的方法调用展开到 Implements 中全部实现的方法，标记为动态调用
+func (a *AstTransverseInfo) BuildCallGraph() {
+	a.CallEdges = make([]*CallEdge, 0)
+	a.callerIndex = make(map[*vs.GoFunc][]*CallEdge)
+	a.calleeIndex = make(map[*vs.GoFunc][]*CallEdge)
+	// 类型检查模式已按类型信息展开接口调用，与按 Implements 展开的边在同一调用点重复；
+	// 调用点按行列区分，导出文档不保留 Offset，解码后重建的调用图同样可用
+	type edgeKey struct {
+		callee    *vs.GoFunc
+		line      int
+		column    int
+		reference bool
+	}
+	for _, caller := range a.SortedFuncs() {
+		seen := make(map[edgeKey]bool)
+		addEdge := func(callee *vs.GoFunc, calleeInfo *vs.CalleeInfo, dynamic bool) {
+			key := edgeKey{callee: callee, line: calleeInfo.Begin.Line, column: calleeInfo.Begin.Column, reference: calleeInfo.Reference}
+			if seen[key] {
+				return
+			}
+			seen[key] = true
+			edge := &CallEdge{
+				Caller:   caller,
+				Callee:   callee,
+				CallSite: calleeInfo,
+				Dynamic:  dynamic,
+			}
+			a.CallEdges = append(a.CallEdges, edge)
+			a.calleeIndex[caller] = append(a.calleeIndex[caller], edge)
+			a.callerIndex[callee] = append(a.callerIndex[callee], edge)
+		}
+		for _, calleeInfo := range caller.CalleeInfos {
+			if callee := a.ResolveCallee(calleeInfo); callee != nil {
+				addEdge(callee, calleeInfo, calleeInfo.Dynamic)
+				continue
+			}
+			if calleeInfo.Receiver == nil {
+				continue
+			}
+			for _, callee := range a.resolveInterfaceMethods(*calleeInfo.Receiver, calleeInfo.Selectors, calleeInfo.Name) {
+				addEdge(callee, calleeInfo, true)
+			}
+		}
+	}
+}
+
+// ResolveCallee 查找调用点对应的函数定义，方法调用不区分调用方变量是否为指针，并沿字段路径和嵌入提升查找
+func (a *AstTransverseInfo) ResolveCallee(calleeInfo *vs.CalleeInfo) *vs.GoFunc {
+	if calleeInfo.Receiver != nil {
+		return a.resolveMethod(*calleeInfo.Receiver, calleeInfo.Selectors, calleeInfo.Name)
+	}
+	return a.GetFunc(calleeInfo.Pkg, calleeInfo.Name)
+}
+
+// GetFunc 按包名和函数标识查找函数，方法的 Type.Method 与 (*Type).Method 写法均可
+func (a *AstTransverseInfo) GetFunc(pkg string, key string) *vs.GoFunc {
+	funcs, ok := a.FuncInfoMap[pkg]
+	if !ok {
+		return nil
+	}
+	if goFunc, ok := funcs[key]; ok {
+		return goFunc
+	}
+	if alternate := vs.AlternateMethodKey(key); alternate != "" {
+		return funcs[alternate]
+	}
+	return nil
+}
+
+// Callers 谁调用了指定函数
+func (a *AstTransverseInfo) Callers(pkg string, key string) []*CallEdge {
+	goFunc := a.GetFunc(pkg, key)
+	if goFunc == nil {
+		return nil
+	}
+	return a.callerIndex[goFunc]
+}
+
+// Callees 指定函数调用了谁
+func (a *AstTransverseInfo) Callees(pkg string, key string) []*CallEdge {
+	goFunc := a.GetFunc(pkg, key)
+	if goFunc == nil {
+		return nil
+	}
+	return a.calleeIndex[goFunc]
+}
+
+// SortedFuncs 按包名、函数标识排序返回全部函数，保证输出稳定
+func (a *AstTransverseInfo) SortedFuncs() []*vs.GoFunc {
+	pkgs := make([]string, 0, len(a.FuncInfoMap))
+	for pkg := range a.FuncInfoMap {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+	funcs := make([]*vs.GoFunc, 0)
+	for _, pkg := range pkgs {
+		keys := make([]string, 0, len(a.FuncInfoMap[pkg]))
+		for key := range a.FuncInfoMap[pkg] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			funcs = append(funcs, a.FuncInfoMap[pkg][key])
+		}
+	}
+	return funcs
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Severity 诊断级别
+type Severity string
+
+const (
+	// SeverityError 文件或包未能完整分析：读取失败、语法错误、构建约束错误、类型错误
+	SeverityError Severity = "error"
+	// SeverityWarning 分析结果可能不准确：重复定义、类型检查整体退化为语法推断
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic 遍历过程中单个文件或目录的问题，出错的文件尽量基于部分结果继续分析，不中断整个遍历
+type Diagnostic struct {
+	// Path 相对 RootDir 的文件或目录路径
+	Path string
+	// Pos 出错位置，无法定位到行时 Line 为 0
+	Pos      token.Position
+	Message  string
+	Severity Severity
+}
+
+func (d *Diagnostic) String() string {
+	location := d.Path
+	if d.Pos.Line > 0 {
+		location = fmt.Sprintf("%s:%d:%d", d.Path, d.Pos.Line, d.Pos.Column)
+	}
+	return fmt.Sprintf("%s: %s: %s", location, d.Severity, d.Message)
+}
+
+// diagnostics 并发遍历时收集诊断
+type diagnostics struct {
+	rootDir string
+	mu      sync.Mutex
+	list    []*Diagnostic
+}
+
+func newDiagnostics(rootDir string) *diagnostics {
+	return &diagnostics{
+		rootDir: rootDir,
+		list:    make([]*Diagnostic, 0),
+	}
+}
+
+// add 记录 path 上的诊断，err 为 scanner.ErrorList 时每个语法错误分别记录位置
+func (d *diagnostics) add(path string, severity Severity, err error) {
+	var errorList scanner.ErrorList
+	if errors.As(err, &errorList) {
+		for _, e := range errorList {
+			d.addAt(path, e.Pos, e.Msg, severity)
+		}
+		return
+	}
+	d.addAt(path, token.Position{}, err.Error(), severity)
+}
+
+// addPackageError 记录 packages.Load 返回的包错误，位置形如 file:line:col 或 file:line，无法定位到文件时归到包目录
+func (d *diagnostics) addPackageError(dir string, pkgErr packages.Error) {
+	path, pos := dir, token.Position{}
+	if file, line, column := splitPosition(pkgErr.Pos); file != "" {
+		path, pos = file, token.Position{Filename: file, Line: line, Column: column}
+	}
+	d.addAt(path, pos, pkgErr.Msg, SeverityError)
+}
+
+func (d *diagnostics) addAt(path string, pos token.Position, message string, severity Severity) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.list = append(d.list, &Diagnostic{
+		Path:     relativePath(d.rootDir, path),
+		Pos:      pos,
+		Message:  message,
+		Severity: severity,
+	})
+}
+
+// sorted 按路径和位置排序并去重，保证并发遍历的输出稳定；测试变体等重复加载的包会报告相同的错误
+func (d *diagnostics) sorted() []*Diagnostic {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	sort.SliceStable(d.list, func(i, j int) bool {
+		a, b := d.list[i], d.list[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		if a.Pos.Line != b.Pos.Line {
+			return a.Pos.Line < b.Pos.Line
+		}
+		return a.Pos.Column < b.Pos.Column
+	})
+	result := make([]*Diagnostic, 0, len(d.list))
+	seen := make(map[string]struct{}, len(d.list))
+	for _, diagnostic := range d.list {
+		key := diagnostic.String()
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		result = append(result, diagnostic)
+	}
+	return result
+}
+
+// splitPosition 从末尾拆分 file:line:col 或 file:line，路径本身可能含冒号，格式不符时 file 为空
+func splitPosition(pos string) (string, int, int) {
+	numbers := make([]int, 0, 2)
+	for len(numbers) < 2 {
+		i := strings.LastIndex(pos, ":")
+		if i < 0 {
+			break
+		}
+		n, err := strconv.Atoi(pos[i+1:])
+		if err != nil {
+			break
+		}
+		numbers = append([]int{n}, numbers...)
+		pos = pos[:i]
+	}
+	switch len(numbers) {
+	case 1:
+		return pos, numbers[0], 0
+	case 2:
+		return pos, numbers[0], numbers[1]
+	default:
+		return "", 0, 0
+	}
+}
+
+// Errors 错误级别的诊断
+func (a *AstTransverseInfo) Errors() []*Diagnostic {
+	errs := make([]*Diagnostic, 0)
+	for _, diagnostic := range a.Diagnostics {
+		if diagnostic.Severity == SeverityError {
+			errs = append(errs, diagnostic)
+		}
+	}
+	return errs
+}
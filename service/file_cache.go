@@ -0,0 +1,143 @@
+package service
+
+import (
+	"ast-callgraph/vs"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// fileCacheVersion 访问器输出结构变化时需要升级，使旧缓存失效
+const fileCacheVersion = "16"
+
+// fileCache 以文件内容和 go.mod、go.work 内容的哈希为键，在磁盘上缓存单文件访问结果
+type fileCache struct {
+	dir string
+	// version 即 fileCacheVersion，计入缓存键
+	version string
+	modHash string
+}
+
+// fileCacheEntry 单文件访问结果，跨文件的调用边不缓存，每次重新解析
+type fileCacheEntry struct {
+	StructInfoMap    map[string][]*vs.StructInfo
+	InterfaceInfoMap map[string][]*vs.InterfaceInfo
+	FuncMap          map[string]*vs.GoFunc
+	ImportedPkgMap   map[string]string
+	VarMap           map[string]*vs.Var
+}
+
+// newFileCache 模块集合和任一 go.mod、go.work 的内容都计入哈希，工作区变化时缓存整体失效
+func newFileCache(dir string, goModPaths ...string) (*fileCache, error) {
+	hash := sha256.New()
+	for _, goModPath := range goModPaths {
+		modContent, err := os.ReadFile(goModPath)
+		if err != nil {
+			return nil, err
+		}
+		hash.Write([]byte(goModPath))
+		hash.Write([]byte{0})
+		hash.Write(modContent)
+		hash.Write([]byte{0})
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &fileCache{
+		dir:     dir,
+		version: fileCacheVersion,
+		modHash: hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
+
+// key 缓存键包含缓存版本、go.mod、文件路径、包名和文件内容
+func (c *fileCache) key(path string, currentPkg string, content []byte) string {
+	hash := sha256.New()
+	for _, part := range []string{c.version, c.modHash, path, currentPkg} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	hash.Write(content)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// withPkgNames 模块内包声明的包名影响导入解析，一并计入哈希
+func (c *fileCache) withPkgNames(pkgNames map[string]string) *fileCache {
+	pkgs := make([]string, 0, len(pkgNames))
+	for pkg := range pkgNames {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+	hash := sha256.New()
+	hash.Write([]byte(c.modHash))
+	for _, pkg := range pkgs {
+		hash.Write([]byte(pkg + " " + pkgNames[pkg]))
+		hash.Write([]byte{0})
+	}
+	return &fileCache{
+		dir:     c.dir,
+		version: c.version,
+		modHash: hex.EncodeToString(hash.Sum(nil)),
+	}
+}
+
+func (c *fileCache) entryPath(key string) string {
+	return filepath.Join(c.dir, key[:2], key+".json")
+}
+
+// load 读取缓存并还原为访问器，缓存不存在或损坏时返回 false
+func (c *fileCache) load(key string) (*vs.FileFuncVisitor, bool) {
+	content, err := os.ReadFile(c.entryPath(key))
+	if err != nil {
+		return nil, false
+	}
+	entry := &fileCacheEntry{}
+	if err := json.Unmarshal(content, entry); err != nil {
+		return nil, false
+	}
+	visitor := &vs.FileFuncVisitor{
+		FileStructVisitor: vs.FileStructVisitor{
+			ImportedPkgMap:   entry.ImportedPkgMap,
+			StructInfoMap:    entry.StructInfoMap,
+			InterfaceInfoMap: entry.InterfaceInfoMap,
+			VarMap:           entry.VarMap,
+		},
+		FuncMap: entry.FuncMap,
+	}
+	return visitor, true
+}
+
+// store 写入缓存，先写临时文件再重命名，避免并发读到不完整内容
+func (c *fileCache) store(key string, visitor *vs.FileFuncVisitor) error {
+	content, err := json.Marshal(&fileCacheEntry{
+		StructInfoMap:    visitor.StructInfoMap,
+		InterfaceInfoMap: visitor.InterfaceInfoMap,
+		FuncMap:          visitor.FuncMap,
+		ImportedPkgMap:   visitor.ImportedPkgMap,
+		VarMap:           visitor.VarMap,
+	})
+	if err != nil {
+		return err
+	}
+	entryPath := c.entryPath(key)
+	if err := os.MkdirAll(filepath.Dir(entryPath), 0o755); err != nil {
+		return err
+	}
+	tmpFile, err := os.CreateTemp(filepath.Dir(entryPath), key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmpFile.Write(content); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	return os.Rename(tmpFile.Name(), entryPath)
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"ast-callgraph/internal/testmod"
+	"ast-callgraph/vs"
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// cacheSource 覆盖方法、匿名函数、包级变量初始化、跨文件调用和接口分派，缓存还原后调用边应与首次分析一致
+var cacheSource = map[string]string{
+	"p/p.go": `package p
+
+type Runner interface{ Run() }
+
+type job struct{ name string }
+
+func (j *job) Run() { helper(j.name) }
+
+var defaultJob = newJob()
+
+func newJob() *job { return &job{} }
+
+func Start(r Runner) {
+	go func() {
+		r.Run()
+	}()
+	j := &job{}
+	j.Run()
+}
+`,
+	"p/helper.go": `package p
+
+func helper(name string) {}
+`,
+	"main.go": `package main
+
+import "example.com/m/p"
+
+func main() { p.Start(nil) }
+`,
+}
+
+func TestFileCache(t *testing.T) {
+	modDir := testmod.Write(t, nil)
+	goModPath := filepath.Join(modDir, "go.mod")
+	cache, err := newFileCache(t.TempDir(), goModPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache = cache.withPkgNames(map[string]string{"example.com/m/p": "p"})
+	content := []byte(cacheSource["p/p.go"])
+	visitor := visitSource(t, content)
+	key := cache.key("p/p.go", "example.com/m/p", content)
+	if _, ok := cache.load(key); ok {
+		t.Fatal("load before store: want miss")
+	}
+	if err := cache.store(key, visitor); err != nil {
+		t.Fatal(err)
+	}
+	// 1.命中时还原出相同的访问结果
+	loaded, ok := cache.load(key)
+	if !ok {
+		t.Fatal("load after store: want hit")
+	}
+	if got, want := marshal(t, loaded.FuncMap), marshal(t, visitor.FuncMap); got != want {
+		t.Errorf("loaded funcs = %s, want %s", got, want)
+	}
+	// 2.文件内容、包名、缓存版本或 go.mod 变化时键不同
+	oldVersion := *cache
+	oldVersion.version = "0"
+	testmod.WriteFile(t, modDir, "go.mod", testmod.GoMod+"\nrequire example.com/dep v1.0.0\n")
+	changedMod, err := newFileCache(cache.dir, goModPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	misses := map[string]string{
+		"content":  cache.key("p/p.go", "example.com/m/p", append(content, '\n')),
+		"path":     cache.key("p/q.go", "example.com/m/p", content),
+		"pkgNames": cache.withPkgNames(map[string]string{"example.com/m/p": "q"}).key("p/p.go", "example.com/m/p", content),
+		"version":  oldVersion.key("p/p.go", "example.com/m/p", content),
+		"go.mod":   changedMod.withPkgNames(map[string]string{"example.com/m/p": "p"}).key("p/p.go", "example.com/m/p", content),
+	}
+	for name, missKey := range misses {
+		if _, ok := cache.load(missKey); ok {
+			t.Errorf("%s changed: want miss", name)
+		}
+	}
+	// 3.损坏的缓存文件视为未命中
+	if err := os.WriteFile(cache.entryPath(key), []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.load(key); ok {
+		t.Error("corrupt entry: want miss")
+	}
+}
+
+func TestTransverseDirectoryCache(t *testing.T) {
+	dir := testmod.Write(t, cacheSource)
+	cacheDir := t.TempDir()
+	want := snapshot(t, analyzeDir(t, dir, &AstTransverseParam{}))
+	for _, run := range []string{"store", "load"} {
+		if got := snapshot(t, analyzeDir(t, dir, &AstTransverseParam{CacheDir: cacheDir})); got != want {
+			t.Errorf("%s run:\n%s\nwant:\n%s", run, got, want)
+		}
+	}
+	entries, err := filepath.Glob(filepath.Join(cacheDir, "*", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(cacheSource) {
+		t.Fatalf("cache entries = %d, want %d", len(entries), len(cacheSource))
+	}
+	// 损坏的缓存文件重新分析，结果不变
+	for _, entry := range entries {
+		if err := os.WriteFile(entry, []byte("not json"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := snapshot(t, analyzeDir(t, dir, &AstTransverseParam{CacheDir: cacheDir})); got != want {
+		t.Errorf("corrupt cache run:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func visitSource(t *testing.T, content []byte) *vs.FileFuncVisitor {
+	t.Helper()
+	fset := token.NewFileSet()
+	astFile, err := parser.ParseFile(fset, "p.go", content, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	visitor := vs.NewFileFuncVisitor("example.com/m", "example.com/m/p", "/m/p/p.go", "p/p.go", fset, content)
+	ast.Walk(visitor, astFile)
+	return visitor
+}
+
+func marshal(t *testing.T, v any) string {
+	t.Helper()
+	content, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"ast-callgraph/internal/testmod"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// analyzeSource 在临时模块 example.com/m 中按语法推断模式分析给定文件，files 为相对模块根目录的路径 -> 内容
+func analyzeSource(t *testing.T, files map[string]string) *AstTransverseInfo {
+	t.Helper()
+	return analyzeDir(t, testmod.Write(t, files), &AstTransverseParam{})
+}
+
+// analyzeDir 分析已写入的目录，存在诊断时测试失败
+func analyzeDir(t *testing.T, dir string, param *AstTransverseParam) *AstTransverseInfo {
+	t.Helper()
+	param.Directory = dir
+	info, err := TransverseDirectory(context.Background(), param)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Diagnostics) > 0 {
+		t.Fatalf("diagnostics: %v", info.Diagnostics)
+	}
+	return info
+}
+
+// snapshot 分析结果中结构体、函数、调用边和诊断的稳定文本形式，用于比较两次分析的结果是否一致
+func snapshot(t *testing.T, info *AstTransverseInfo) string {
+	t.Helper()
+	var b strings.Builder
+	for _, v := range []any{info.StructInfoMap, info.FuncInfoMap} {
+		content, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.Write(content)
+		b.WriteString("\n")
+	}
+	for _, edge := range info.CallEdges {
+		fmt.Fprintf(&b, "%s.%s -> %s.%s @%d:%d dynamic=%v reference=%v\n", edge.Caller.Pkg, edge.Caller.Key(),
+			edge.Callee.Pkg, edge.Callee.Key(), edge.CallSite.Begin.Line, edge.CallSite.Begin.Column, edge.Dynamic, edge.CallSite.Reference)
+	}
+	for _, diagnostic := range info.Diagnostics {
+		fmt.Fprintf(&b, "%s:%d:%d %s %s\n", diagnostic.Path, diagnostic.Pos.Line, diagnostic.Pos.Column, diagnostic.Severity, diagnostic.Message)
+	}
+	return b.String()
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"ast-callgraph/vs"
+	"sort"
+	"strings"
+)
+
+// Implementation 接口的一个实现，Pointer 为 true 表示只有 *T 的方法集满足接口
+type Implementation struct {
+	Struct  *vs.StructInfo
+	Pointer bool
+}
+
+// methodSigs 方法名 -> 省略参数名的签名
+type methodSigs map[string]string
+
+// BuildImplements 按方法集计算模块内结构体对接口的实现关系，方法集包含指针接收者方法和嵌入提升的方法
+//
+// 嵌入了模块外接口、含类型约束元素或带类型参数的接口方法集无法确定，不计算实现；空接口不计算实现
+func (a *AstTransverseInfo) BuildImplements() {
+	a.Implements = make(map[string][]*Implementation)
+	resolver := &methodSetResolver{
+		info:       a,
+		structs:    make(map[string]*vs.StructInfo),
+		interfaces: make(map[string]*vs.InterfaceInfo),
+	}
+	for _, structInfos := range a.StructInfoMap {
+		for _, structInfo := range structInfos {
+			resolver.structs[structInfo.TypeName] = structInfo
+		}
+	}
+	for _, interfaceInfos := range a.InterfaceInfoMap {
+		for _, interfaceInfo := range interfaceInfos {
+			resolver.interfaces[interfaceInfo.TypeName] = interfaceInfo
+		}
+	}
+	structs := make([]*vs.StructInfo, 0, len(resolver.structs))
+	for _, structInfo := range resolver.structs {
+		structs = append(structs, structInfo)
+	}
+	sort.Slice(structs, func(i, j int) bool {
+		return structs[i].TypeName < structs[j].TypeName
+	})
+	for typeName, interfaceInfo := range resolver.interfaces {
+		required, ok := resolver.interfaceMethods(interfaceInfo, map[string]bool{})
+		if !ok || len(required) == 0 {
+			continue
+		}
+		implementations := make([]*Implementation, 0)
+		for _, structInfo := range structs {
+			if satisfies(resolver.structMethods(structInfo, false), required) {
+				implementations = append(implementations, &Implementation{Struct: structInfo})
+			} else if satisfies(resolver.structMethods(structInfo, true), required) {
+				implementations = append(implementations, &Implementation{Struct: structInfo, Pointer: true})
+			}
+		}
+		if len(implementations) > 0 {
+			a.Implements[typeName] = implementations
+		}
+	}
+}
+
+// GetInterface 按包名和接口名查找接口
+func (a *AstTransverseInfo) GetInterface(pkg string, name string) *vs.InterfaceInfo {
+	for _, interfaceInfo := range a.InterfaceInfoMap[pkg] {
+		if interfaceInfo.Name == name {
+			return interfaceInfo
+		}
+	}
+	return nil
+}
+
+func satisfies(methods methodSigs, required methodSigs) bool {
+	for name, signature := range required {
+		if methods[name] != signature {
+			return false
+		}
+	}
+	return true
+}
+
+type methodSetResolver struct {
+	info       *AstTransverseInfo
+	structs    map[string]*vs.StructInfo
+	interfaces map[string]*vs.InterfaceInfo
+}
+
+// interfaceMethods 接口的完整方法集，包含嵌入接口的方法，无法确定时返回 false
+func (r *methodSetResolver) interfaceMethods(interfaceInfo *vs.InterfaceInfo, visiting map[string]bool) (methodSigs, bool) {
+	if len(interfaceInfo.TypeParams) > 0 || visiting[interfaceInfo.TypeName] {
+		return nil, false
+	}
+	visiting[interfaceInfo.TypeName] = true
+	defer delete(visiting, interfaceInfo.TypeName)
+	methods := make(methodSigs, len(interfaceInfo.Methods))
+	for _, method := range interfaceInfo.Methods {
+		methods[method.Name] = method.Signature
+	}
+	for _, embed := range interfaceInfo.Embeds {
+		embedded, ok := r.interfaces[embed]
+		if !ok {
+			return nil, false
+		}
+		embeddedMethods, ok := r.interfaceMethods(embedded, visiting)
+		if !ok {
+			return nil, false
+		}
+		for name, signature := range embeddedMethods {
+			methods[name] = signature
+		}
+	}
+	return methods, true
+}
+
+// structMethods 结构体 T 或 *T 的方法集，与 promote 一致按嵌入深度逐层计算：浅层遮蔽深层，同一深度重名时有歧义，不计入方法集
+//
+// 嵌入 E 时 T 获得 E 的值接收者方法、*T 获得 E 的全部方法，嵌入 *E 时两者都获得 E 的全部方法
+func (r *methodSetResolver) structMethods(structInfo *vs.StructInfo, pointer bool) methodSigs {
+	methods := make(methodSigs)
+	// 自身的字段和方法遮蔽提升的同名方法，不在方法集中的指针接收者方法同样遮蔽
+	blocked := make(map[string]bool)
+	for _, field := range structInfo.Fields {
+		blocked[field.Name] = true
+	}
+	for _, method := range structInfo.Methods {
+		blocked[method.Name] = true
+		if method.PointerReceiver && !pointer {
+			continue
+		}
+		if goFunc := r.info.GetFunc(structInfo.Pkg, method.Key); goFunc != nil {
+			methods[method.Name] = goFunc.Signature()
+		}
+	}
+	type embedding struct {
+		typeName string
+		// addressable 路径上有指针接收者或指针嵌入，可获得指针接收者方法
+		addressable bool
+	}
+	embeddings := func(fields []*vs.Field, addressable bool) []embedding {
+		result := make([]embedding, 0)
+		for _, field := range fields {
+			if field.Embedded {
+				result = append(result, embedding{typeName: baseTypeName(field.Type), addressable: addressable || strings.HasPrefix(field.Type, "*")})
+			}
+		}
+		return result
+	}
+	// promotedSig 同一深度的候选，字段的 signature 为空
+	type promotedSig struct {
+		signature string
+		inSet     bool
+	}
+	level := embeddings(structInfo.Fields, pointer)
+	visited := map[string]bool{structInfo.TypeName: true}
+	for len(level) > 0 {
+		found := make(map[string][]promotedSig)
+		next := make([]embedding, 0)
+		for _, node := range level {
+			if embedded, ok := r.interfaces[node.typeName]; ok {
+				interfaceMethods, _ := r.interfaceMethods(embedded, map[string]bool{})
+				for name, signature := range interfaceMethods {
+					found[name] = append(found[name], promotedSig{signature: signature, inSet: true})
+				}
+				continue
+			}
+			// 与 promote 相同，同一深度经多条路径到达的类型每条路径都计入候选
+			embedded, ok := r.structs[node.typeName]
+			if !ok || visited[node.typeName] {
+				continue
+			}
+			for _, field := range embedded.Fields {
+				found[field.Name] = append(found[field.Name], promotedSig{})
+			}
+			next = append(next, embeddings(embedded.Fields, node.addressable)...)
+			for _, method := range embedded.Methods {
+				goFunc := r.info.GetFunc(embedded.Pkg, method.Key)
+				if goFunc == nil {
+					continue
+				}
+				found[method.Name] = append(found[method.Name], promotedSig{
+					signature: goFunc.Signature(),
+					inSet:     !method.PointerReceiver || node.addressable,
+				})
+			}
+		}
+		for name, candidates := range found {
+			if blocked[name] {
+				continue
+			}
+			blocked[name] = true
+			if len(candidates) == 1 && candidates[0].inSet {
+				methods[name] = candidates[0].signature
+			}
+		}
+		for _, node := range level {
+			visited[node.typeName] = true
+		}
+		level = next
+	}
+	return methods
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+const implementsSource = `package p
+
+import "io"
+
+type Reader interface{ Read(p []byte) (n int, err error) }
+
+type Closer interface{ Close() error }
+
+type ReadCloser interface {
+	Reader
+	Closer
+}
+
+type Logger interface{ Log(msg string) }
+
+type External interface {
+	io.Writer
+}
+
+type Empty interface{}
+
+type Generic[T any] interface{ Get() T }
+
+type file struct{}
+
+func (file) Read(buf []byte) (int, error) { return 0, nil }
+func (*file) Close() error              { return nil }
+
+type wrongSig struct{}
+
+func (wrongSig) Read(p []byte) int { return 0 }
+
+// embedded 经值嵌入获得 Read，经指针嵌入获得 Close
+type embedded struct {
+	file
+}
+
+type embeddedPtr struct {
+	*file
+}
+
+// ambiguous 深度 1 的两个 Log 有歧义，方法集中没有 Log
+type ambiguous struct {
+	stdLogger
+	jsonLogger
+}
+
+type stdLogger struct{}
+
+func (stdLogger) Log(msg string) {}
+
+type jsonLogger struct{}
+
+func (jsonLogger) Log(msg string) {}
+
+// shadowed 深度 1 的 Log 签名不符，遮蔽深度 2 签名相符的 Log
+type shadowed struct {
+	wrapper
+	badLogger
+}
+
+type badLogger struct{}
+
+func (badLogger) Log(msg string, level int) {}
+
+type wrapper struct{ stdLogger }
+
+// shallow 深度 1 的 Log 遮蔽深度 2 的歧义
+type shallow struct {
+	stdLogger
+	ambiguous
+}
+
+// diamond 经 left 和 right 在同一深度两次到达 stdLogger，Log 有歧义
+type diamond struct {
+	left
+	right
+}
+
+type left struct{ stdLogger }
+
+type right struct{ stdLogger }
+
+// viaInterface 嵌入接口获得接口的方法
+type viaInterface struct {
+	Logger
+}
+`
+
+func TestBuildImplements(t *testing.T) {
+	info := analyzeSource(t, map[string]string{"p/p.go": implementsSource})
+	tests := []struct {
+		iface string
+		want  []string
+	}{
+		{iface: "Reader", want: []string{"embedded", "embeddedPtr", "file"}},
+		{iface: "Closer", want: []string{"*embedded", "*file", "embeddedPtr"}},
+		{iface: "ReadCloser", want: []string{"*embedded", "*file", "embeddedPtr"}},
+		{iface: "Logger", want: []string{"jsonLogger", "left", "right", "shallow", "stdLogger", "viaInterface", "wrapper"}},
+		// 嵌入模块外接口、空接口和泛型接口的方法集无法确定或没有意义，不计算实现
+		{iface: "External", want: nil},
+		{iface: "Empty", want: nil},
+		{iface: "Generic", want: nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, implementation := range info.Implements["example.com/m/p."+tt.iface] {
+			name := implementation.Struct.Name
+			if implementation.Pointer {
+				name = "*" + name
+			}
+			got = append(got, name)
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("implementations of %s = %v, want %v", tt.iface, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"ast-callgraph/vs"
+	"sort"
+)
+
+// LinkMethods 将方法关联到接收者所属结构体的方法集，方法与结构体可能位于同包的不同文件；随后计算经嵌入提升的字段和方法
+func (a *AstTransverseInfo) LinkMethods() {
+	a.indexStructs()
+	for _, structInfo := range a.structsByType {
+		structInfo.Methods = nil
+	}
+	for _, goFunc := range a.SortedFuncs() {
+		if !goFunc.IsMethod() {
+			continue
+		}
+		structInfo, ok := a.structsByType[baseTypeName(goFunc.RecvType.Type)]
+		if !ok {
+			continue
+		}
+		structInfo.Methods = append(structInfo.Methods, vs.MethodIndex{
+			Name:            goFunc.Name,
+			Key:             goFunc.Key(),
+			PointerReceiver: goFunc.PointerReceiver(),
+		})
+	}
+	for _, structInfo := range a.structsByType {
+		sort.Slice(structInfo.Methods, func(i, j int) bool {
+			return structInfo.Methods[i].Name < structInfo.Methods[j].Name
+		})
+	}
+	for _, structInfo := range a.structsByType {
+		a.promote(structInfo)
+	}
+}
+
+// MethodSet 结构体的方法，pointer 为 false 时仅返回值接收者方法，与 Go 方法集规则一致
+func (a *AstTransverseInfo) MethodSet(structInfo *vs.StructInfo, pointer bool) []*vs.GoFunc {
+	methods := make([]*vs.GoFunc, 0, len(structInfo.Methods))
+	for _, method := range structInfo.Methods {
+		if method.PointerReceiver && !pointer {
+			continue
+		}
+		if goFunc := a.GetFunc(structInfo.Pkg, method.Key); goFunc != nil {
+			methods = append(methods, goFunc)
+		}
+	}
+	return methods
+}
+
+// indexStructs 按完整类型名索引结构体
+func (a *AstTransverseInfo) indexStructs() {
+	a.structsByType = make(map[string]*vs.StructInfo)
+	for pkg, structInfos := range a.StructInfoMap {
+		for _, structInfo := range structInfos {
+			a.structsByType[pkg+"."+structInfo.Name] = structInfo
+		}
+	}
+}
+
+// baseTypeName 去掉指针和类型实参的完整类型名
+func baseTypeName(fullType string) string {
+	pkg, typeName := vs.SplitTypeName(fullType)
+	return pkg + "." + typeName
+}
+
+// promotedEntry 某一嵌入深度上的同名候选
+type promotedEntry struct {
+	field  *vs.Field
+	method *vs.MethodIndex
+	owner  *vs.StructInfo
+	via    []string
+}
+
+// promote 按嵌入深度逐层计算提升的字段和方法：浅层遮蔽深层，同一深度重名时有歧义，两者都不提升
+func (a *AstTransverseInfo) promote(structInfo *vs.StructInfo) {
+	structInfo.PromotedFields = nil
+	structInfo.PromotedMethods = nil
+	blocked := make(map[string]bool)
+	for _, field := range structInfo.Fields {
+		blocked[field.Name] = true
+	}
+	for _, method := range structInfo.Methods {
+		blocked[method.Name] = true
+	}
+	type embedding struct {
+		typeName string
+		via      []string
+	}
+	level := make([]embedding, 0)
+	for _, field := range structInfo.Fields {
+		if field.Embedded {
+			level = append(level, embedding{typeName: baseTypeName(field.Type), via: []string{field.Name}})
+		}
+	}
+	visited := map[string]bool{structInfo.TypeName: true}
+	for len(level) > 0 {
+		found := make(map[string][]*promotedEntry)
+		next := make([]embedding, 0)
+		for _, node := range level {
+			// 嵌入的模块内接口：方法名参与遮蔽，但没有对应的函数定义
+			if interfaceInfo := a.findInterface(node.typeName); interfaceInfo != nil {
+				for _, method := range interfaceInfo.Methods {
+					found[method.Name] = append(found[method.Name], &promotedEntry{via: node.via})
+				}
+				continue
+			}
+			// 只跳过更浅深度已展开的类型；同一深度经多条路径到达的类型每条路径都计入候选，如 T 嵌入 A、B 且两者都嵌入 C 时 C 的方法有歧义
+			embedded, ok := a.structsByType[node.typeName]
+			if !ok || visited[node.typeName] {
+				continue
+			}
+			for _, field := range embedded.Fields {
+				found[field.Name] = append(found[field.Name], &promotedEntry{field: field, owner: embedded, via: node.via})
+				if field.Embedded {
+					via := append(append(make([]string, 0, len(node.via)+1), node.via...), field.Name)
+					next = append(next, embedding{typeName: baseTypeName(field.Type), via: via})
+				}
+			}
+			for i := range embedded.Methods {
+				method := &embedded.Methods[i]
+				found[method.Name] = append(found[method.Name], &promotedEntry{method: method, owner: embedded, via: node.via})
+			}
+		}
+		for name, entries := range found {
+			if blocked[name] {
+				continue
+			}
+			blocked[name] = true
+			if len(entries) != 1 {
+				continue
+			}
+			entry := entries[0]
+			if entry.field != nil {
+				structInfo.PromotedFields = append(structInfo.PromotedFields, vs.PromotedField{
+					Name: name,
+					Type: entry.field.Type,
+					Via:  entry.via,
+				})
+			} else if entry.method != nil {
+				structInfo.PromotedMethods = append(structInfo.PromotedMethods, vs.PromotedMethod{
+					Name:            name,
+					Pkg:             entry.owner.Pkg,
+					Key:             entry.method.Key,
+					PointerReceiver: entry.method.PointerReceiver,
+					Via:             entry.via,
+				})
+			}
+		}
+		for _, node := range level {
+			visited[node.typeName] = true
+		}
+		level = next
+	}
+	sort.Slice(structInfo.PromotedFields, func(i, j int) bool {
+		return structInfo.PromotedFields[i].Name < structInfo.PromotedFields[j].Name
+	})
+	sort.Slice(structInfo.PromotedMethods, func(i, j int) bool {
+		return structInfo.PromotedMethods[i].Name < structInfo.PromotedMethods[j].Name
+	})
+}
+
+// findInterface 按完整类型名查找模块内接口
+func (a *AstTransverseInfo) findInterface(typeName string) *vs.InterfaceInfo {
+	pkg, name := vs.SplitTypeName(typeName)
+	return a.GetInterface(pkg, name)
+}
+
+// fieldType 结构体字段的类型，依次查找自身字段和提升字段
+func (a *AstTransverseInfo) fieldType(structInfo *vs.StructInfo, name string) (string, bool) {
+	for _, field := range structInfo.Fields {
+		if field.Name == name {
+			return field.Type, true
+		}
+	}
+	for _, field := range structInfo.PromotedFields {
+		if field.Name == name {
+			return field.Type, true
+		}
+	}
+	return "", false
+}
+
+// resolveMethod 解析 recv.s1.s2.name() 调用的方法：先沿字段路径确定类型，再查找自身方法和提升方法
+func (a *AstTransverseInfo) resolveMethod(recvType string, selectors []string, name string) *vs.GoFunc {
+	current, ok := a.resolveReceiver(recvType, selectors)
+	if !ok {
+		return nil
+	}
+	pkg, typeName := vs.SplitTypeName(current)
+	return a.structMethod(pkg, typeName, name)
+}
+
+// resolveInterfaceMethods 接收者为模块内接口时，展开为全部实现结构体的同名方法(含提升方法)
+func (a *AstTransverseInfo) resolveInterfaceMethods(recvType string, selectors []string, name string) []*vs.GoFunc {
+	current, ok := a.resolveReceiver(recvType, selectors)
+	if !ok {
+		return nil
+	}
+	methods := make([]*vs.GoFunc, 0)
+	for _, implementation := range a.Implements[baseTypeName(current)] {
+		if goFunc := a.structMethod(implementation.Struct.Pkg, implementation.Struct.Name, name); goFunc != nil {
+			methods = append(methods, goFunc)
+		}
+	}
+	return methods
+}
+
+// resolveReceiver 沿字段路径确定最终接收者的类型，路径上的类型不是模块内结构体时返回 false
+func (a *AstTransverseInfo) resolveReceiver(recvType string, selectors []string) (string, bool) {
+	if a.structsByType == nil {
+		a.indexStructs()
+	}
+	current := recvType
+	for _, selector := range selectors {
+		structInfo, ok := a.structsByType[baseTypeName(current)]
+		if !ok {
+			return "", false
+		}
+		if current, ok = a.fieldType(structInfo, selector); !ok {
+			return "", false
+		}
+	}
+	return current, true
+}
+
+// structMethod 类型自身声明的方法，不存在时查找经嵌入提升的方法
+func (a *AstTransverseInfo) structMethod(pkg string, typeName string, name string) *vs.GoFunc {
+	if goFunc := a.GetFunc(pkg, vs.MethodKey(typeName, false, name)); goFunc != nil {
+		return goFunc
+	}
+	if structInfo, ok := a.structsByType[pkg+"."+typeName]; ok {
+		for _, method := range structInfo.PromotedMethods {
+			if method.Name == name {
+				return a.GetFunc(method.Pkg, method.Key)
+			}
+		}
+	}
+	return nil
+}
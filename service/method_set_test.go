@@ -0,0 +1,161 @@
+package service
+
+import (
+	"ast-callgraph/vs"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// promotionSource Service 在深度 1 同时从 Logger 和 Tracer 获得 Log 和 Level，两者都有歧义；
+// Inner.Deep 在深度 2 的 Log 被深度 1 的歧义遮蔽；自身的 Name 遮蔽 Inner 的 Name；A、B 互相嵌入；
+// Diamond 经 Left 和 Right 在同一深度两次到达 Base
+const promotionSource = `package p
+
+type Logger struct{ Level int }
+
+func (Logger) Log()    {}
+func (*Logger) Close() {}
+
+type Tracer struct{ Level int }
+
+func (Tracer) Log()   {}
+func (Tracer) Trace() {}
+
+type Deep struct{ X int }
+
+func (Deep) Log()    {}
+func (*Deep) Deeper() {}
+
+type Inner struct{ Deep }
+
+func (Inner) Name() string { return "" }
+
+type Service struct {
+	Logger
+	Tracer
+	*Inner
+}
+
+func (Service) Name() string { return "" }
+
+type A struct{ *B }
+
+func (A) FromA() {}
+
+type B struct {
+	*A
+	Y int
+}
+
+func (B) FromB() {}
+
+type Base struct{ Z int }
+
+func (Base) M() {}
+
+type Left struct{ Base }
+
+type Right struct{ Base }
+
+type Diamond struct {
+	Left
+	Right
+}
+`
+
+func TestPromote(t *testing.T) {
+	info := analyzeSource(t, map[string]string{"p/p.go": promotionSource})
+	tests := []struct {
+		typeName string
+		fields   []string
+		methods  []string
+	}{
+		{
+			typeName: "Service",
+			fields:   []string{"Deep via Inner", "X via Inner.Deep"},
+			methods:  []string{"Close (*Logger).Close via Logger", "Deeper (*Deep).Deeper via Inner.Deep", "Trace Tracer.Trace via Tracer"},
+		},
+		{typeName: "Inner", fields: []string{"X via Deep"}, methods: []string{"Deeper (*Deep).Deeper via Deep", "Log Deep.Log via Deep"}},
+		// 互相嵌入时遍历终止，a.A 即 a.B.A
+		{typeName: "A", fields: []string{"A via B", "Y via B"}, methods: []string{"FromB B.FromB via B"}},
+		{typeName: "B", fields: []string{"B via A"}, methods: []string{"FromA A.FromA via A"}},
+		// 同一深度的两条路径到达同一类型，Base、Z 和 M 都有歧义
+		{typeName: "Diamond", fields: []string{}, methods: []string{}},
+	}
+	for _, tt := range tests {
+		structInfo := info.structsByType["example.com/m/p."+tt.typeName]
+		if structInfo == nil {
+			t.Errorf("%s: struct not found", tt.typeName)
+			continue
+		}
+		fields := make([]string, 0)
+		for _, field := range structInfo.PromotedFields {
+			fields = append(fields, field.Name+" via "+strings.Join(field.Via, "."))
+		}
+		methods := make([]string, 0)
+		for _, method := range structInfo.PromotedMethods {
+			methods = append(methods, method.Name+" "+method.Key+" via "+strings.Join(method.Via, "."))
+		}
+		if !reflect.DeepEqual(fields, tt.fields) {
+			t.Errorf("%s promoted fields = %q, want %q", tt.typeName, fields, tt.fields)
+		}
+		if !reflect.DeepEqual(methods, tt.methods) {
+			t.Errorf("%s promoted methods = %q, want %q", tt.typeName, methods, tt.methods)
+		}
+	}
+}
+
+func TestResolveMethod(t *testing.T) {
+	info := analyzeSource(t, map[string]string{"p/p.go": promotionSource})
+	tests := []struct {
+		recvType  string
+		selectors []string
+		name      string
+		want      string
+	}{
+		{recvType: "example.com/m/p.Service", name: "Name", want: "Service.Name"},
+		{recvType: "*example.com/m/p.Service", name: "Close", want: "(*Logger).Close"},
+		{recvType: "example.com/m/p.Service", name: "Deeper", want: "(*Deep).Deeper"},
+		// 深度 1 的歧义不会退到更深的 Deep.Log
+		{recvType: "example.com/m/p.Service", name: "Log", want: ""},
+		// 经字段路径访问后不再有歧义
+		{recvType: "example.com/m/p.Service", selectors: []string{"Tracer"}, name: "Log", want: "Tracer.Log"},
+		{recvType: "example.com/m/p.Service", selectors: []string{"Inner"}, name: "Log", want: "Deep.Log"},
+		{recvType: "example.com/m/p.Service", selectors: []string{"Inner", "Deep"}, name: "Deeper", want: "(*Deep).Deeper"},
+		// 歧义的字段无法继续解析
+		{recvType: "example.com/m/p.Service", selectors: []string{"Level"}, name: "String", want: ""},
+		{recvType: "example.com/m/p.Service", selectors: []string{"Missing"}, name: "Log", want: ""},
+		{recvType: "example.com/m/p.A", name: "FromB", want: "B.FromB"},
+		{recvType: "example.com/m/p.A", selectors: []string{"B", "A"}, name: "FromA", want: "A.FromA"},
+		{recvType: "example.com/m/p.Diamond", name: "M", want: ""},
+		{recvType: "example.com/m/p.Diamond", selectors: []string{"Left"}, name: "M", want: "Base.M"},
+	}
+	for _, tt := range tests {
+		got := ""
+		if goFunc := info.resolveMethod(tt.recvType, tt.selectors, tt.name); goFunc != nil {
+			got = goFunc.Key()
+		}
+		if got != tt.want {
+			t.Errorf("resolveMethod(%s, %v, %s) = %q, want %q", tt.recvType, tt.selectors, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMethodSet(t *testing.T) {
+	info := analyzeSource(t, map[string]string{"p/p.go": promotionSource})
+	structInfo := info.structsByType["example.com/m/p.Logger"]
+	keys := func(methods []*vs.GoFunc) []string {
+		result := make([]string, 0, len(methods))
+		for _, goFunc := range methods {
+			result = append(result, goFunc.Key())
+		}
+		return result
+	}
+	if got := keys(info.MethodSet(structInfo, false)); !reflect.DeepEqual(got, []string{"Logger.Log"}) {
+		t.Errorf("MethodSet(Logger) = %v", got)
+	}
+	if got := keys(info.MethodSet(structInfo, true)); !reflect.DeepEqual(got, []string{"(*Logger).Close", "Logger.Log"}) {
+		t.Errorf("MethodSet(*Logger) = %v", got)
+	}
+}
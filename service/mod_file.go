@@ -0,0 +1,146 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+)
+
+// parseGoMod 解析单个 go.mod，ModPath 和本地替换目录转换为绝对路径
+func parseGoMod(goModPath string) (*ModFileInfo, error) {
+	goModPath, err := filepath.Abs(goModPath)
+	if err != nil {
+		return nil, err
+	}
+	modFileContent, err := os.ReadFile(goModPath)
+	if err != nil {
+		return nil, err
+	}
+	modFile, err := modfile.Parse(goModPath, modFileContent, nil)
+	if err != nil {
+		return nil, err
+	}
+	if modFile == nil || modFile.Module == nil {
+		return nil, errors.New("module directive not found")
+	}
+	m := &ModFileInfo{
+		RootPkg:  modFile.Module.Mod.Path,
+		ModPath:  goModPath,
+		DepsMods: make([]*DepsMod, 0, len(modFile.Require)),
+		Replaces: newReplaceMods(filepath.Dir(goModPath), modFile.Replace),
+		Excludes: make([]*ExcludeMod, 0, len(modFile.Exclude)),
+		Retracts: make([]*RetractRange, 0, len(modFile.Retract)),
+	}
+	if modFile.Go != nil {
+		m.GoVersion = modFile.Go.Version
+	}
+	if modFile.Toolchain != nil {
+		m.Toolchain = modFile.Toolchain.Name
+	}
+	for _, require := range modFile.Require {
+		m.DepsMods = append(m.DepsMods, &DepsMod{
+			Pkg:      require.Mod.Path,
+			Tag:      require.Mod.Version,
+			Indirect: require.Indirect,
+		})
+	}
+	for _, exclude := range modFile.Exclude {
+		m.Excludes = append(m.Excludes, &ExcludeMod{
+			Pkg: exclude.Mod.Path,
+			Tag: exclude.Mod.Version,
+		})
+	}
+	for _, retract := range modFile.Retract {
+		m.Retracts = append(m.Retracts, &RetractRange{
+			Low:       retract.Low,
+			High:      retract.High,
+			Rationale: retract.Rationale,
+		})
+	}
+	return m, nil
+}
+
+// newReplaceMods 转换 replace 指令，本地路径相对 go.mod(或 go.work)所在目录
+func newReplaceMods(baseDir string, replaces []*modfile.Replace) []*ReplaceMod {
+	result := make([]*ReplaceMod, 0, len(replaces))
+	for _, replace := range replaces {
+		r := &ReplaceMod{
+			OldPkg: replace.Old.Path,
+			OldTag: replace.Old.Version,
+			NewPkg: replace.New.Path,
+			NewTag: replace.New.Version,
+		}
+		if modfile.IsDirectoryPath(replace.New.Path) {
+			dir := filepath.FromSlash(replace.New.Path)
+			if !filepath.IsAbs(dir) {
+				dir = filepath.Join(baseDir, dir)
+			}
+			r.Dir = filepath.Clean(dir)
+		}
+		result = append(result, r)
+	}
+	return result
+}
+
+// Dir 模块根目录的绝对路径
+func (m *ModFileInfo) Dir() string {
+	dir, err := filepath.Abs(filepath.Dir(m.ModPath))
+	if err != nil {
+		return filepath.Dir(m.ModPath)
+	}
+	return dir
+}
+
+// Require 依赖模块的 require 信息，未声明时返回 nil
+func (m *ModFileInfo) Require(pkg string) *DepsMod {
+	for _, dep := range m.DepsMods {
+		if dep.Pkg == pkg {
+			return dep
+		}
+	}
+	return nil
+}
+
+// Replacement 模块版本生效的替换，指定版本的替换优先于全部版本的替换，未替换时返回 nil
+func (m *ModFileInfo) Replacement(pkg string, tag string) *ReplaceMod {
+	return findReplacement(m.Replaces, pkg, tag)
+}
+
+func findReplacement(replaces []*ReplaceMod, pkg string, tag string) *ReplaceMod {
+	var wildcard *ReplaceMod
+	for _, replace := range replaces {
+		if replace.OldPkg != pkg {
+			continue
+		}
+		if replace.OldTag == tag && tag != "" {
+			return replace
+		}
+		if replace.OldTag == "" {
+			wildcard = replace
+		}
+	}
+	return wildcard
+}
+
+// IsExcluded 模块版本是否被 exclude
+func (m *ModFileInfo) IsExcluded(pkg string, tag string) bool {
+	for _, exclude := range m.Excludes {
+		if exclude.Pkg == pkg && exclude.Tag == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRetracted 本模块的版本是否被撤回
+func (m *ModFileInfo) IsRetracted(tag string) bool {
+	for _, retract := range m.Retracts {
+		if semver.Compare(retract.Low, tag) <= 0 && semver.Compare(tag, retract.High) <= 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"ast-callgraph/internal/testmod"
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const modFileSource = `module example.com/m
+
+go 1.22
+
+toolchain go1.22.3
+
+require (
+	example.com/a v1.2.0
+	example.com/b v0.3.0 // indirect
+	example.com/c v1.0.0
+)
+
+replace example.com/a => ../a
+
+replace example.com/b v0.3.0 => example.com/fork/b v0.3.1
+
+replace example.com/b => example.com/other/b v0.4.0
+
+exclude example.com/c v1.1.0
+
+retract (
+	v1.0.1 // broken build
+	[v1.1.0, v1.1.5]
+)
+`
+
+func TestParseGoMod(t *testing.T) {
+	dir := testmod.Write(t, map[string]string{"go.mod": modFileSource})
+	m, err := parseGoMod(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.RootPkg != "example.com/m" || m.GoVersion != "1.22" || m.Toolchain != "go1.22.3" || m.Dir() != dir {
+		t.Errorf("module = %s go %s toolchain %s dir %s", m.RootPkg, m.GoVersion, m.Toolchain, m.Dir())
+	}
+	deps := make([]string, 0)
+	for _, dep := range m.DepsMods {
+		deps = append(deps, fmt.Sprintf("%s %s indirect=%v", dep.Pkg, dep.Tag, dep.Indirect))
+	}
+	wantDeps := []string{"example.com/a v1.2.0 indirect=false", "example.com/b v0.3.0 indirect=true", "example.com/c v1.0.0 indirect=false"}
+	if !reflect.DeepEqual(deps, wantDeps) {
+		t.Errorf("deps = %q, want %q", deps, wantDeps)
+	}
+	if got := m.Require("example.com/b"); got == nil || got.Tag != "v0.3.0" {
+		t.Errorf("Require(example.com/b) = %v", got)
+	}
+	if got := m.Require("example.com/missing"); got != nil {
+		t.Errorf("Require(example.com/missing) = %v, want nil", got)
+	}
+	retracts := make([]string, 0)
+	for _, retract := range m.Retracts {
+		retracts = append(retracts, fmt.Sprintf("[%s, %s] %s", retract.Low, retract.High, retract.Rationale))
+	}
+	wantRetracts := []string{"[v1.0.1, v1.0.1] broken build", "[v1.1.0, v1.1.5] "}
+	if !reflect.DeepEqual(retracts, wantRetracts) {
+		t.Errorf("retracts = %q, want %q", retracts, wantRetracts)
+	}
+}
+
+func TestModFileDirectives(t *testing.T) {
+	dir := testmod.Write(t, map[string]string{"go.mod": modFileSource})
+	m, err := parseGoMod(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	replacements := []struct {
+		pkg  string
+		tag  string
+		want string
+	}{
+		// 本地路径替换相对 go.mod 所在目录
+		{pkg: "example.com/a", tag: "v1.2.0", want: "../a dir=" + filepath.Join(filepath.Dir(dir), "a")},
+		// 指定版本的替换优先于全部版本的替换
+		{pkg: "example.com/b", tag: "v0.3.0", want: "example.com/fork/b v0.3.1 dir="},
+		{pkg: "example.com/b", tag: "v0.2.0", want: "example.com/other/b v0.4.0 dir="},
+		{pkg: "example.com/c", tag: "v1.0.0", want: ""},
+	}
+	for _, tt := range replacements {
+		got := ""
+		if replace := m.Replacement(tt.pkg, tt.tag); replace != nil {
+			got = fmt.Sprintf("%s dir=%s", replace.NewPkg, replace.Dir)
+			if replace.NewTag != "" {
+				got = fmt.Sprintf("%s %s dir=%s", replace.NewPkg, replace.NewTag, replace.Dir)
+			}
+		}
+		if got != tt.want {
+			t.Errorf("Replacement(%s, %s) = %q, want %q", tt.pkg, tt.tag, got, tt.want)
+		}
+	}
+	excludes := []struct {
+		pkg  string
+		tag  string
+		want bool
+	}{
+		{pkg: "example.com/c", tag: "v1.1.0", want: true},
+		{pkg: "example.com/c", tag: "v1.0.0", want: false},
+		{pkg: "example.com/a", tag: "v1.1.0", want: false},
+	}
+	for _, tt := range excludes {
+		if got := m.IsExcluded(tt.pkg, tt.tag); got != tt.want {
+			t.Errorf("IsExcluded(%s, %s) = %v, want %v", tt.pkg, tt.tag, got, tt.want)
+		}
+	}
+	retracts := []struct {
+		tag  string
+		want bool
+	}{
+		{tag: "v1.0.1", want: true},
+		{tag: "v1.1.0", want: true},
+		{tag: "v1.1.3", want: true},
+		{tag: "v1.1.5", want: true},
+		{tag: "v1.1.6", want: false},
+		{tag: "v1.0.0", want: false},
+		{tag: "", want: false},
+	}
+	for _, tt := range retracts {
+		if got := m.IsRetracted(tt.tag); got != tt.want {
+			t.Errorf("IsRetracted(%q) = %v, want %v", tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestParseGoModInvalid(t *testing.T) {
+	tests := map[string]string{
+		"no module directive": "go 1.21\n",
+		"syntax error":        "module example.com/m\n\nrequire (\n",
+	}
+	for name, content := range tests {
+		dir := testmod.Write(t, map[string]string{"go.mod": content})
+		if _, err := parseGoMod(filepath.Join(dir, "go.mod")); err == nil {
+			t.Errorf("%s: want error", name)
+		}
+	}
+}
+
+// TestWorkspaceIsRetracted 跟随本地替换的依赖模块在自身 go.mod 中撤回了被依赖的版本
+func TestWorkspaceIsRetracted(t *testing.T) {
+	dir := testmod.Write(t, map[string]string{
+		"go.mod":   "module example.com/m\n\ngo 1.21\n\nrequire example.com/a v1.0.1\n\nreplace example.com/a => ./a\n",
+		"a/go.mod": "module example.com/a\n\ngo 1.21\n\nretract v1.0.1\n",
+	})
+	workspace, err := DiscoverModules(context.Background(), &AstTransverseParam{Directory: dir, GoWorkPath: goWorkOff, FollowReplaces: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		pkg  string
+		tag  string
+		want bool
+	}{
+		{pkg: "example.com/a", tag: "v1.0.1", want: true},
+		{pkg: "example.com/a", tag: "v1.0.2", want: false},
+		// 分析范围外的依赖无法得知撤回信息
+		{pkg: "example.com/b", tag: "v1.0.1", want: false},
+	}
+	for _, tt := range tests {
+		if got := workspace.IsRetracted(tt.pkg, tt.tag); got != tt.want {
+			t.Errorf("IsRetracted(%s, %s) = %v, want %v", tt.pkg, tt.tag, got, tt.want)
+		}
+	}
+}
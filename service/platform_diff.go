@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"sort"
+)
+
+// PlatformDiff 多个平台调用图的差异，只包含并非所有平台都存在的函数和调用边
+type PlatformDiff struct {
+	Platforms []string
+	Funcs     []*PlatformFunc
+	Edges     []*PlatformEdge
+}
+
+// PlatformFunc 只在部分平台定义的函数，File、Line 取第一个包含它的平台
+type PlatformFunc struct {
+	Func      string
+	File      string
+	Line      int
+	Platforms []string
+}
+
+// PlatformEdge 只在部分平台存在的调用边，File、Line 为第一个包含它的平台中的调用位置
+type PlatformEdge struct {
+	Caller    string
+	Callee    string
+	File      string
+	Line      int
+	Platforms []string
+}
+
+// DiffPlatforms 按每个平台分别分析目录并比较函数和调用边，其余参数对所有平台相同
+func DiffPlatforms(ctx context.Context, param *AstTransverseParam, platforms []Platform) (*PlatformDiff, error) {
+	diff := &PlatformDiff{
+		Platforms: make([]string, 0, len(platforms)),
+		Funcs:     make([]*PlatformFunc, 0),
+		Edges:     make([]*PlatformEdge, 0),
+	}
+	funcs := make(map[string]*PlatformFunc)
+	edges := make(map[[2]string]*PlatformEdge)
+	seenPlatforms := make(map[string]bool)
+	for _, platform := range platforms {
+		name := platform.String()
+		// 重复指定的平台只分析一次，否则只在该平台存在的函数和调用边计数不足
+		if seenPlatforms[name] {
+			continue
+		}
+		seenPlatforms[name] = true
+		platformParam := *param
+		platformParam.GOOS = platform.GOOS
+		platformParam.GOARCH = platform.GOARCH
+		info, err := TransverseDirectory(ctx, &platformParam)
+		if err != nil {
+			return nil, err
+		}
+		diff.Platforms = append(diff.Platforms, name)
+		for _, goFunc := range info.SortedFuncs() {
+			id := goFunc.Pkg + "." + goFunc.Key()
+			if _, ok := funcs[id]; !ok {
+				funcs[id] = &PlatformFunc{
+					Func: id,
+					File: goFunc.RFile,
+					Line: goFunc.Begin.Line,
+				}
+			}
+			funcs[id].Platforms = append(funcs[id].Platforms, name)
+		}
+		// 同一平台内同一对函数间的多条调用边只记录一次平台
+		platformEdges := make(map[[2]string]bool)
+		for _, edge := range info.CallEdges {
+			id := [2]string{edge.Caller.Pkg + "." + edge.Caller.Key(), edge.Callee.Pkg + "." + edge.Callee.Key()}
+			if platformEdges[id] {
+				continue
+			}
+			platformEdges[id] = true
+			if _, ok := edges[id]; !ok {
+				edges[id] = &PlatformEdge{
+					Caller: id[0],
+					Callee: id[1],
+					File:   edge.Caller.RFile,
+					Line:   edge.CallSite.Begin.Line,
+				}
+			}
+			edges[id].Platforms = append(edges[id].Platforms, name)
+		}
+	}
+	for _, f := range funcs {
+		if len(f.Platforms) < len(diff.Platforms) {
+			diff.Funcs = append(diff.Funcs, f)
+		}
+	}
+	for _, e := range edges {
+		if len(e.Platforms) < len(diff.Platforms) {
+			diff.Edges = append(diff.Edges, e)
+		}
+	}
+	sort.Slice(diff.Funcs, func(i, j int) bool {
+		return diff.Funcs[i].Func < diff.Funcs[j].Func
+	})
+	sort.Slice(diff.Edges, func(i, j int) bool {
+		if diff.Edges[i].Caller != diff.Edges[j].Caller {
+			return diff.Edges[i].Caller < diff.Edges[j].Caller
+		}
+		return diff.Edges[i].Callee < diff.Edges[j].Callee
+	})
+	return diff, nil
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"ast-callgraph/internal/testmod"
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// platformSource setup 在 linux 和 windows 上分别由不同文件定义，linux 版本两次调用 linuxOnly
+var platformSource = map[string]string{
+	"main.go": `package main
+
+func main() {
+	setup()
+	common()
+}
+
+func common() {}
+`,
+	"setup_linux.go": `package main
+
+func setup() {
+	linuxOnly()
+	linuxOnly()
+}
+
+func linuxOnly() {}
+`,
+	"setup_windows.go": `package main
+
+func setup() { windowsOnly() }
+
+func windowsOnly() {}
+`,
+}
+
+func TestDiffPlatforms(t *testing.T) {
+	dir := testmod.Write(t, platformSource)
+	linux := Platform{GOOS: "linux", GOARCH: "amd64"}
+	windows := Platform{GOOS: "windows", GOARCH: "amd64"}
+	// 重复的平台只计一次
+	diff, err := DiffPlatforms(context.Background(), &AstTransverseParam{Directory: dir}, []Platform{linux, windows, linux})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"linux/amd64", "windows/amd64"}; !reflect.DeepEqual(diff.Platforms, want) {
+		t.Errorf("platforms = %v, want %v", diff.Platforms, want)
+	}
+	funcs := make([]string, 0)
+	for _, f := range diff.Funcs {
+		funcs = append(funcs, fmt.Sprintf("%s %s:%d %v", f.Func, f.File, f.Line, f.Platforms))
+	}
+	wantFuncs := []string{
+		"example.com/m.linuxOnly setup_linux.go:8 [linux/amd64]",
+		"example.com/m.windowsOnly setup_windows.go:5 [windows/amd64]",
+	}
+	if !reflect.DeepEqual(funcs, wantFuncs) {
+		t.Errorf("funcs = %q, want %q", funcs, wantFuncs)
+	}
+	edges := make([]string, 0)
+	for _, e := range diff.Edges {
+		edges = append(edges, fmt.Sprintf("%s -> %s %s:%d %v", e.Caller, e.Callee, e.File, e.Line, e.Platforms))
+	}
+	wantEdges := []string{
+		"example.com/m.setup -> example.com/m.linuxOnly setup_linux.go:4 [linux/amd64]",
+		"example.com/m.setup -> example.com/m.windowsOnly setup_windows.go:3 [windows/amd64]",
+	}
+	if !reflect.DeepEqual(edges, wantEdges) {
+		t.Errorf("edges = %q, want %q", edges, wantEdges)
+	}
+}
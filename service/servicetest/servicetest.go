@@ -0,0 +1,32 @@
+// Package servicetest 基于临时模块的分析结果，供 service 之外的包测试使用
+package servicetest
+
+import (
+	"ast-callgraph/internal/testmod"
+	"ast-callgraph/service"
+	"context"
+	"testing"
+)
+
+// Analyze 写入临时模块并分析，param 为空时按语法推断模式分析；存在诊断时测试失败
+func Analyze(t testing.TB, files map[string]string, param *service.AstTransverseParam) *service.AstTransverseInfo {
+	t.Helper()
+	return AnalyzeDir(t, testmod.Write(t, files), param)
+}
+
+// AnalyzeDir 分析已写入的目录，param 为空时按语法推断模式分析；存在诊断时测试失败
+func AnalyzeDir(t testing.TB, dir string, param *service.AstTransverseParam) *service.AstTransverseInfo {
+	t.Helper()
+	if param == nil {
+		param = &service.AstTransverseParam{}
+	}
+	param.Directory = dir
+	info, err := service.TransverseDirectory(context.Background(), param)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Diagnostics) > 0 {
+		t.Fatalf("diagnostics: %v", info.Diagnostics)
+	}
+	return info
+}
@@ -0,0 +1,245 @@
+package service
+
+import (
+	"ast-callgraph/vs"
+	"context"
+	"errors"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"golang.org/x/tools/go/packages"
+)
+
+// typeCheckLoadMode 依赖包同样从源码类型检查，避免依赖 go list -export 导出数据与工具链版本耦合
+const typeCheckLoadMode = packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles |
+	packages.NeedImports | packages.NeedDeps | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo
+
+// typedLoad 一次 packages.Load 的工作目录、模式和 GOWORK 设置
+type typedLoad struct {
+	dir      string
+	patterns []string
+	goWork   string
+}
+
+// typedLoads 类型检查的加载批次：go.work 中的模块一次加载以共享类型，其他模块各自加载，
+// 嵌套模块不属于外层模块的 ./...，分析目录位于模块内时只加载该目录
+func (w *Workspace) typedLoads() []*typedLoad {
+	loads := make([]*typedLoad, 0)
+	var workLoad *typedLoad
+	if w.Work != nil {
+		workLoad = &typedLoad{
+			dir:    w.Directory,
+			goWork: w.Work.WorkPath,
+		}
+	}
+	for _, m := range w.Modules {
+		var dir string
+		switch {
+		case isSubDir(w.Directory, m.Dir()):
+			dir = m.Dir()
+		case isSubDir(m.Dir(), w.Directory):
+			dir = w.Directory
+		case w.isReplaced(m):
+			dir = m.Dir()
+		default:
+			// 分析目录之外的工作区模块不加载
+			continue
+		}
+		if workLoad != nil && w.inWork(m) && !w.isReplaced(m) {
+			pattern := "./..."
+			if rel, err := filepath.Rel(w.Directory, dir); err == nil && rel != "." {
+				pattern = "./" + filepath.ToSlash(rel) + "/..."
+			}
+			workLoad.patterns = append(workLoad.patterns, pattern)
+			continue
+		}
+		loads = append(loads, &typedLoad{
+			dir:      dir,
+			patterns: []string{"./..."},
+			goWork:   goWorkOff,
+		})
+	}
+	if workLoad != nil && len(workLoad.patterns) > 0 {
+		loads = append([]*typedLoad{workLoad}, loads...)
+	}
+	return loads
+}
+
+// env go 命令的环境变量，工作区模式不允许 -mod=mod，从 GOFLAGS 中移除 -mod
+func (l *typedLoad) env() []string {
+	env := append(os.Environ(), "GOWORK="+l.goWork)
+	if l.goWork == goWorkOff {
+		return env
+	}
+	goFlags := make([]string, 0)
+	for _, flag := range strings.Fields(os.Getenv("GOFLAGS")) {
+		if !strings.HasPrefix(flag, "-mod=") && !strings.HasPrefix(flag, "--mod=") {
+			goFlags = append(goFlags, flag)
+		}
+	}
+	return append(env, "GOFLAGS="+strings.Join(goFlags, " "))
+}
+
+// transverseTypedPackages 加载带类型信息的包并遍历，类型检查失败的包退化为按名称推断
+func transverseTypedPackages(ctx context.Context, param *AstTransverseParam, workspace *Workspace, astTransverseInfo *AstTransverseInfo) error {
+	rootDir := workspace.RootDir()
+	fileSet := token.NewFileSet()
+	pkgs := make([]*packages.Package, 0)
+	for _, load := range workspace.typedLoads() {
+		loaded, err := packages.Load(&packages.Config{
+			Context:    ctx,
+			Mode:       typeCheckLoadMode,
+			Dir:        load.dir,
+			Env:        append(load.env(), param.goEnv()...),
+			BuildFlags: param.buildFlags(),
+			Tests:      param.IncludeTests,
+			Fset:       fileSet,
+		}, load.patterns...)
+		if err != nil {
+			return err
+		}
+		pkgs = append(pkgs, loaded...)
+	}
+	if len(pkgs) == 0 {
+		return errors.New("no packages loaded")
+	}
+	if param.IncludeTests {
+		pkgs = testVariants(pkgs)
+	}
+	implementers := newImplementerIndex(pkgs)
+	// 展开为文件列表后与语法模式共用并发遍历
+	type typedFile struct {
+		pkg     *packages.Package
+		astFile *ast.File
+		path    string
+		module  *ModFileInfo
+	}
+	diagnostics := astTransverseInfo.diagnostics
+	files := make([]*typedFile, 0)
+	for _, pkg := range pkgs {
+		if len(pkg.Errors) > 0 {
+			hlog.CtxWarnf(ctx, "transverseTypedPackages %s type check err %v, fallback to syntactic mode", pkg.PkgPath, pkg.Errors[0])
+			dir := pkg.Dir
+			if dir == "" {
+				dir = param.Directory
+			}
+			for _, pkgErr := range pkg.Errors {
+				diagnostics.addPackageError(dir, pkgErr)
+			}
+		}
+		for _, astFile := range pkg.Syntax {
+			path := fileSet.Position(astFile.Pos()).Filename
+			if !strings.HasSuffix(path, ".go") || !param.matchFile(relativePath(rootDir, path)) || param.inExcludedDir(rootDir, path) {
+				continue
+			}
+			if param.SkipGenerated && ast.IsGenerated(astFile) {
+				continue
+			}
+			module := workspace.ModuleOf(path)
+			if module == nil {
+				continue
+			}
+			files = append(files, &typedFile{
+				pkg:     pkg,
+				astFile: astFile,
+				path:    path,
+				module:  module,
+			})
+		}
+	}
+	return astTransverseInfo.visitFiles(ctx, param.workers(), len(files), func(i int) (*vs.FileFuncVisitor, error) {
+		file := files[i]
+		fileContent, err := os.ReadFile(file.path)
+		if err != nil {
+			hlog.CtxWarnf(ctx, "transverseTypedPackages ReadFile err %v", err)
+			diagnostics.add(file.path, SeverityError, err)
+			return nil, nil
+		}
+		visitor := vs.NewFileFuncVisitor(file.module.RootPkg, file.pkg.PkgPath, file.path, relativePath(rootDir, file.path), fileSet, fileContent)
+		visitor.ModulePaths = workspace.ModulePaths()
+		if len(file.pkg.Errors) == 0 {
+			visitor.TypesInfo = file.pkg.TypesInfo
+			visitor.Implementers = implementers.Implementers
+		}
+		// panic 之前已采集的声明仍然保留
+		if err := walkFile(visitor, file.astFile); err != nil {
+			hlog.CtxWarnf(ctx, "transverseTypedPackages walk %s err %v", file.path, err)
+			diagnostics.add(file.path, SeverityError, err)
+		}
+		return visitor, nil
+	})
+}
+
+// testVariants 加载测试时同一个包还有包含 _test.go 的测试变体 "pkg [pkg.test]"，只保留测试变体，
+// 避免同一文件访问两次、同一类型在实现索引中出现两次；生成的 pkg.test 主包不在模块目录内，遍历时被忽略
+func testVariants(pkgs []*packages.Package) []*packages.Package {
+	hasVariant := make(map[string]bool)
+	for _, pkg := range pkgs {
+		if pkg.ID != pkg.PkgPath && strings.HasPrefix(pkg.ID, pkg.PkgPath+" [") {
+			hasVariant[pkg.PkgPath] = true
+		}
+	}
+	result := make([]*packages.Package, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		if pkg.ID == pkg.PkgPath && hasVariant[pkg.PkgPath] {
+			continue
+		}
+		result = append(result, pkg)
+	}
+	return result
+}
+
+// implementerIndex 模块内具体类型索引，按接口缓存实现类型(CHA)
+type implementerIndex struct {
+	mu      sync.Mutex
+	types   []*types.Named
+	byIface map[*types.Interface][]*types.Named
+}
+
+func newImplementerIndex(pkgs []*packages.Package) *implementerIndex {
+	index := &implementerIndex{
+		byIface: make(map[*types.Interface][]*types.Named),
+	}
+	for _, pkg := range pkgs {
+		if pkg.Types == nil {
+			continue
+		}
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			typeName, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || typeName.IsAlias() {
+				continue
+			}
+			named, ok := typeName.Type().(*types.Named)
+			// 接口本身和未实例化的泛型类型不作为实现类型
+			if !ok || named.TypeParams().Len() > 0 || types.IsInterface(named) {
+				continue
+			}
+			index.types = append(index.types, named)
+		}
+	}
+	return index
+}
+
+// Implementers 返回值类型或指针类型实现了接口的模块内类型
+func (i *implementerIndex) Implementers(iface *types.Interface) []*types.Named {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if result, ok := i.byIface[iface]; ok {
+		return result
+	}
+	result := make([]*types.Named, 0)
+	for _, named := range i.types {
+		if types.Implements(named, iface) || types.Implements(types.NewPointer(named), iface) {
+			result = append(result, named)
+		}
+	}
+	i.byIface[iface] = result
+	return result
+}
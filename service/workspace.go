@@ -0,0 +1,364 @@
+package service
+
+import (
+	"ast-callgraph/vs"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"golang.org/x/mod/modfile"
+)
+
+// goWorkOff GoWorkPath 取该值时不使用工作区，与 GOWORK=off 一致
+const goWorkOff = "off"
+
+// WorkFileInfo go.work 信息
+type WorkFileInfo struct {
+	WorkPath  string
+	GoVersion string
+	// Uses use 指令声明的模块根目录，绝对路径
+	Uses []string
+	// Replaces go.work 中的 replace 指令，优先于各模块 go.mod 中的替换
+	Replaces []*ReplaceMod
+}
+
+// Workspace 分析范围内的模块集合
+type Workspace struct {
+	// Directory 分析目录的绝对路径
+	Directory string
+	// Work 生效的 go.work，未使用工作区时为空
+	Work *WorkFileInfo
+	// Modules 全部模块，第一个为主模块，其余按目录排序，跟随的本地替换模块在最后
+	Modules []*ModFileInfo
+	// ReplacedDirs 跟随本地替换引入且不在分析目录下的模块目录，与分析目录一同遍历
+	ReplacedDirs []string
+}
+
+// Main 主模块：-gomod 指定的模块或分析目录所属的模块，都不存在时为第一个发现的模块
+func (w *Workspace) Main() *ModFileInfo {
+	return w.Modules[0]
+}
+
+// RootDir 文件相对路径的基准目录：使用工作区时为 go.work 所在目录，分析目录属于主模块时为主模块根目录，否则为分析目录
+func (w *Workspace) RootDir() string {
+	if w.Work != nil {
+		return filepath.Dir(w.Work.WorkPath)
+	}
+	if mainDir := w.Main().Dir(); isSubDir(mainDir, w.Directory) {
+		return mainDir
+	}
+	return w.Directory
+}
+
+// ModuleOf 文件所属模块，即根目录包含该文件的最深模块，不属于任何模块时返回 nil
+func (w *Workspace) ModuleOf(path string) *ModFileInfo {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		absPath = path
+	}
+	var owner *ModFileInfo
+	for _, m := range w.Modules {
+		if isSubDir(m.Dir(), absPath) && (owner == nil || len(m.Dir()) > len(owner.Dir())) {
+			owner = m
+		}
+	}
+	return owner
+}
+
+// ModulePaths 全部模块路径
+func (w *Workspace) ModulePaths() []string {
+	paths := make([]string, 0, len(w.Modules))
+	for _, m := range w.Modules {
+		paths = append(paths, m.RootPkg)
+	}
+	return paths
+}
+
+// goModPaths 全部 go.mod 以及 go.work 的路径，内容变化时缓存失效
+func (w *Workspace) goModPaths() []string {
+	paths := make([]string, 0, len(w.Modules)+1)
+	if w.Work != nil {
+		paths = append(paths, w.Work.WorkPath)
+	}
+	for _, m := range w.Modules {
+		paths = append(paths, m.ModPath)
+	}
+	return paths
+}
+
+// inWork 模块是否在 go.work 的 use 列表中
+func (w *Workspace) inWork(m *ModFileInfo) bool {
+	if w.Work == nil {
+		return false
+	}
+	for _, use := range w.Work.Uses {
+		if use == m.Dir() {
+			return true
+		}
+	}
+	return false
+}
+
+// DiscoverModules 发现分析范围内的模块：go.work 声明的模块、-gomod 指定或分析目录所属的模块、分析目录下嵌套的 go.mod
+func DiscoverModules(ctx context.Context, param *AstTransverseParam) (*Workspace, error) {
+	directory, err := filepath.Abs(param.Directory)
+	if err != nil {
+		return nil, err
+	}
+	w := &Workspace{
+		Directory: directory,
+	}
+	// a.工作区
+	goWorkPath, err := findGoWorkPath(param.GoWorkPath, directory)
+	if err != nil {
+		return nil, err
+	}
+	if goWorkPath != "" {
+		if w.Work, err = ParseWorkFile(goWorkPath); err != nil {
+			hlog.CtxWarnf(ctx, "DiscoverModules ParseWorkFile err %v", err)
+			return nil, err
+		}
+	}
+	// b.主模块，使用工作区且分析目录不属于任何模块时允许不存在
+	var mainModPath string
+	if param.GoModPath != nil {
+		mainModPath = *param.GoModPath
+	} else if path, err := FindGoModPath(directory); err == nil {
+		mainModPath = path
+	} else if w.Work == nil {
+		hlog.CtxDebugf(ctx, "DiscoverModules FindGoModPath err %v, looking for nested modules", err)
+	}
+	// c.候选 go.mod：主模块、工作区模块、嵌套模块
+	goModPaths := make([]string, 0)
+	if mainModPath != "" {
+		goModPaths = append(goModPaths, mainModPath)
+	}
+	if w.Work != nil {
+		for _, use := range w.Work.Uses {
+			goModPaths = append(goModPaths, filepath.Join(use, "go.mod"))
+		}
+	}
+	nested, err := findNestedGoMods(ctx, param, discoveryRootDir(w, mainModPath), directory)
+	if err != nil {
+		return nil, err
+	}
+	goModPaths = append(goModPaths, nested...)
+	seen := make(map[string]struct{})
+	for _, goModPath := range goModPaths {
+		m, err := parseGoMod(goModPath)
+		if err != nil {
+			hlog.CtxWarnf(ctx, "DiscoverModules parseGoMod %s err %v", goModPath, err)
+			return nil, err
+		}
+		if _, ok := seen[m.Dir()]; ok {
+			continue
+		}
+		seen[m.Dir()] = struct{}{}
+		w.Modules = append(w.Modules, m)
+	}
+	if len(w.Modules) == 0 {
+		return nil, fmt.Errorf("go.mod not found from %s", param.Directory)
+	}
+	// 主模块保持在首位，其余按目录排序保证输出稳定
+	others := w.Modules
+	if mainModPath != "" {
+		others = w.Modules[1:]
+	}
+	sort.Slice(others, func(i, j int) bool {
+		return others[i].Dir() < others[j].Dir()
+	})
+	// d.本地路径替换的依赖模块
+	if param.FollowReplaces {
+		if err := w.followReplaces(ctx, seen); err != nil {
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// followReplaces 加入替换为本地目录的依赖模块；与 go 命令一致只应用主模块和工作区模块的替换，不递归跟随
+func (w *Workspace) followReplaces(ctx context.Context, seen map[string]struct{}) error {
+	for _, m := range w.Modules[:len(w.Modules):len(w.Modules)] {
+		for _, dep := range m.DepsMods {
+			replace := w.Replacement(m, dep.Pkg, dep.Tag)
+			if replace == nil || replace.Dir == "" {
+				continue
+			}
+			if _, ok := seen[replace.Dir]; ok {
+				continue
+			}
+			seen[replace.Dir] = struct{}{}
+			replaced, err := parseGoMod(filepath.Join(replace.Dir, "go.mod"))
+			if err != nil {
+				// 目标目录缺失时 go 命令同样无法构建，只跳过该依赖
+				hlog.CtxWarnf(ctx, "DiscoverModules replace %s => %s err %v", dep.Pkg, replace.Dir, err)
+				continue
+			}
+			w.Modules = append(w.Modules, replaced)
+			if !isSubDir(w.Directory, replaced.Dir()) {
+				w.ReplacedDirs = append(w.ReplacedDirs, replaced.Dir())
+			}
+		}
+	}
+	return nil
+}
+
+// IsRetracted 依赖版本是否被撤回，只能判断依赖模块在分析范围内(工作区模块或跟随的本地替换)时其 go.mod 中的 retract
+func (w *Workspace) IsRetracted(pkg string, tag string) bool {
+	for _, m := range w.Modules {
+		if m.RootPkg == pkg && m.IsRetracted(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Replacement 模块中依赖版本生效的替换，go.work 中的替换优先
+func (w *Workspace) Replacement(m *ModFileInfo, pkg string, tag string) *ReplaceMod {
+	if w.Work != nil {
+		if replace := findReplacement(w.Work.Replaces, pkg, tag); replace != nil {
+			return replace
+		}
+	}
+	return m.Replacement(pkg, tag)
+}
+
+// walkRoots 需要遍历的目录：分析目录和跟随本地替换引入的模块目录
+func (w *Workspace) walkRoots(directory string) []string {
+	return append([]string{directory}, w.ReplacedDirs...)
+}
+
+// isReplaced 模块是否为跟随本地替换引入、不在分析目录下的模块
+func (w *Workspace) isReplaced(m *ModFileInfo) bool {
+	for _, dir := range w.ReplacedDirs {
+		if dir == m.Dir() {
+			return true
+		}
+	}
+	return false
+}
+
+// findGoWorkPath 按 go 命令的规则定位 go.work：显式指定 off 或环境变量 GOWORK=off 时不使用工作区，
+// 指定路径时直接使用，否则从分析目录逐级向上查找，未找到时返回空
+func findGoWorkPath(goWorkPath string, directory string) (string, error) {
+	if goWorkPath == "" {
+		goWorkPath = os.Getenv("GOWORK")
+	}
+	if goWorkPath == goWorkOff {
+		return "", nil
+	}
+	if goWorkPath != "" {
+		return filepath.Abs(goWorkPath)
+	}
+	dir := directory
+	for {
+		path := filepath.Join(dir, "go.work")
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// ParseWorkFile 解析 go.work，use 目录转换为绝对路径
+func ParseWorkFile(goWorkPath string) (*WorkFileInfo, error) {
+	content, err := os.ReadFile(goWorkPath)
+	if err != nil {
+		return nil, err
+	}
+	workFile, err := modfile.ParseWork(goWorkPath, content, nil)
+	if err != nil {
+		return nil, err
+	}
+	w := &WorkFileInfo{
+		WorkPath: goWorkPath,
+		Uses:     make([]string, 0, len(workFile.Use)),
+	}
+	if workFile.Go != nil {
+		w.GoVersion = workFile.Go.Version
+	}
+	workDir := filepath.Dir(goWorkPath)
+	w.Replaces = newReplaceMods(workDir, workFile.Replace)
+	for _, use := range workFile.Use {
+		dir := filepath.FromSlash(use.Path)
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(workDir, dir)
+		}
+		w.Uses = append(w.Uses, filepath.Clean(dir))
+	}
+	return w, nil
+}
+
+// discoveryRootDir 嵌套模块加入前即可确定的 RootDir，与 Workspace.RootDir 一致，Excludes 按它的相对路径匹配
+func discoveryRootDir(w *Workspace, mainModPath string) string {
+	if w.Work != nil {
+		return filepath.Dir(w.Work.WorkPath)
+	}
+	if mainDir := filepath.Dir(mainModPath); mainModPath != "" && isSubDir(mainDir, w.Directory) {
+		return mainDir
+	}
+	return w.Directory
+}
+
+// findNestedGoMods 查找目录下(不含目录本身)的 go.mod，与 go 命令一致跳过 vendor、testdata 以及 . 和 _ 开头的目录，
+// Excludes 匹配的目录同样跳过，其中的模块不参与分析
+func findNestedGoMods(ctx context.Context, param *AstTransverseParam, rootDir string, directory string) ([]string, error) {
+	goModPaths := make([]string, 0)
+	err := filepath.WalkDir(directory, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !d.IsDir() || path == directory {
+			return nil
+		}
+		if isIgnoredDir(d.Name(), false) || matchAnyPattern(param.Excludes, relativePath(rootDir, path)) {
+			return filepath.SkipDir
+		}
+		goModPath := filepath.Join(path, "go.mod")
+		if info, err := os.Stat(goModPath); err == nil && !info.IsDir() {
+			goModPaths = append(goModPaths, goModPath)
+		}
+		return nil
+	})
+	return goModPaths, err
+}
+
+// isSubDir path 是否为 dir 本身或位于 dir 之下
+func isSubDir(dir string, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// IsModulePkg 包是否属于分析范围内的某个模块
+func (a *AstTransverseInfo) IsModulePkg(pkg string) bool {
+	for _, modPath := range a.ModulePaths() {
+		if vs.InModule(pkg, modPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// ModulePaths 分析范围内全部模块路径，未记录模块列表时为主模块路径
+func (a *AstTransverseInfo) ModulePaths() []string {
+	if len(a.Modules) == 0 {
+		return []string{a.RootPkg}
+	}
+	paths := make([]string, 0, len(a.Modules))
+	for _, m := range a.Modules {
+		paths = append(paths, m.RootPkg)
+	}
+	return paths
+}
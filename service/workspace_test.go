@@ -0,0 +1,148 @@
+package service
+
+import (
+	"ast-callgraph/internal/testmod"
+	"context"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// workspaceSource 根目录模块 example.com/m 之外，go.work 声明 a、b 两个模块，a 下嵌套未声明的模块 a/c；
+// testdata、vendor 和 Excludes 匹配目录中的 go.mod 不参与分析
+var workspaceSource = map[string]string{
+	"go.work":  "go 1.22\n\nuse (\n\t./a\n\t./b\n)\n\nreplace example.com/x => ./x\n",
+	"a/go.mod": "module example.com/a\n\ngo 1.22\n",
+	"a/a.go": `package a
+
+import "example.com/b"
+
+func A() { b.B() }
+`,
+	"a/c/go.mod": "module example.com/c\n\ngo 1.22\n",
+	"a/c/sub/sub.go": `package sub
+
+func Sub() {}
+`,
+	"b/go.mod": "module example.com/b\n\ngo 1.22\n",
+	"b/b.go": `package b
+
+func B() {}
+`,
+	"a/testdata/go.mod": "module example.com/ignored\n",
+	"vendor/go.mod":     "module example.com/vendored\n",
+	"tools/go.mod":      "module example.com/tools\n",
+}
+
+func TestParseWorkFile(t *testing.T) {
+	dir := testmod.Write(t, workspaceSource)
+	w, err := ParseWorkFile(filepath.Join(dir, "go.work"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.GoVersion != "1.22" {
+		t.Errorf("go version = %s, want 1.22", w.GoVersion)
+	}
+	if want := []string{filepath.Join(dir, "a"), filepath.Join(dir, "b")}; !reflect.DeepEqual(w.Uses, want) {
+		t.Errorf("uses = %v, want %v", w.Uses, want)
+	}
+	if len(w.Replaces) != 1 || w.Replaces[0].OldPkg != "example.com/x" || w.Replaces[0].Dir != filepath.Join(dir, "x") {
+		t.Errorf("replaces = %+v, want example.com/x => %s", w.Replaces, filepath.Join(dir, "x"))
+	}
+}
+
+func TestFindGoWorkPath(t *testing.T) {
+	t.Setenv("GOWORK", "")
+	dir := testmod.Write(t, workspaceSource)
+	other := testmod.Write(t, nil)
+	tests := []struct {
+		name       string
+		goWorkPath string
+		directory  string
+		want       string
+	}{
+		{name: "work dir", directory: dir, want: filepath.Join(dir, "go.work")},
+		{name: "search upward", directory: filepath.Join(dir, "a", "c", "sub"), want: filepath.Join(dir, "go.work")},
+		{name: "off", goWorkPath: goWorkOff, directory: dir, want: ""},
+		{name: "explicit", goWorkPath: filepath.Join(dir, "go.work"), directory: other, want: filepath.Join(dir, "go.work")},
+		{name: "not found", directory: other, want: ""},
+	}
+	for _, tt := range tests {
+		got, err := findGoWorkPath(tt.goWorkPath, tt.directory)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: findGoWorkPath = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	t.Setenv("GOWORK", goWorkOff)
+	if got, _ := findGoWorkPath("", dir); got != "" {
+		t.Errorf("GOWORK=off: findGoWorkPath = %q, want empty", got)
+	}
+}
+
+func TestDiscoverModules(t *testing.T) {
+	t.Setenv("GOWORK", "")
+	dir := testmod.Write(t, workspaceSource)
+	w, err := DiscoverModules(context.Background(), &AstTransverseParam{Directory: dir, Excludes: []string{"tools"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 分析目录所属的模块为主模块，其余按目录排序
+	if want := []string{"example.com/m", "example.com/a", "example.com/c", "example.com/b"}; !reflect.DeepEqual(w.ModulePaths(), want) {
+		t.Errorf("modules = %v, want %v", w.ModulePaths(), want)
+	}
+	if w.RootDir() != dir {
+		t.Errorf("RootDir = %s, want %s", w.RootDir(), dir)
+	}
+	owners := []struct {
+		path string
+		want string
+	}{
+		{path: "a/a.go", want: "example.com/a"},
+		// 嵌套模块中的文件属于最深的模块
+		{path: "a/c/sub/sub.go", want: "example.com/c"},
+		{path: "b/b.go", want: "example.com/b"},
+		// Excludes 匹配目录中的 go.mod 被跳过，文件属于上层模块
+		{path: "tools/main.go", want: "example.com/m"},
+	}
+	for _, tt := range owners {
+		got := ""
+		if m := w.ModuleOf(filepath.Join(dir, tt.path)); m != nil {
+			got = m.RootPkg
+		}
+		if got != tt.want {
+			t.Errorf("ModuleOf(%s) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+	// 不使用工作区时只发现分析目录所属的模块和嵌套模块
+	w, err = DiscoverModules(context.Background(), &AstTransverseParam{Directory: filepath.Join(dir, "a"), GoWorkPath: goWorkOff})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"example.com/a", "example.com/c"}; w.Work != nil || !reflect.DeepEqual(w.ModulePaths(), want) {
+		t.Errorf("GOWORK=off modules = %v, want %v", w.ModulePaths(), want)
+	}
+}
+
+func TestTransverseWorkspace(t *testing.T) {
+	t.Setenv("GOWORK", "")
+	dir := testmod.Write(t, workspaceSource)
+	info := analyzeDir(t, dir, &AstTransverseParam{Excludes: []string{"tools"}})
+	pkgs := make([]string, 0, len(info.FuncInfoMap))
+	for pkg := range info.FuncInfoMap {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+	if want := []string{"example.com/a", "example.com/b", "example.com/c/sub"}; !reflect.DeepEqual(pkgs, want) {
+		t.Errorf("packages = %v, want %v", pkgs, want)
+	}
+	// 跨模块调用解析到工作区中的模块
+	callees := info.Callees("example.com/a", "A")
+	if len(callees) != 1 || callees[0].Callee.Pkg != "example.com/b" || callees[0].Callee.Key() != "B" {
+		t.Errorf("callees of a.A = %v, want example.com/b.B", callees)
+	}
+}
@@ -0,0 +1,120 @@
+// Package testreach 从测试函数出发计算调用图可达性，报告每个测试传递到达的生产代码函数和没有任何测试到达的函数
+package testreach
+
+import (
+	"ast-callgraph/query"
+	"ast-callgraph/service"
+	"ast-callgraph/vs"
+	"sort"
+	"strings"
+)
+
+// Options 起点配置
+type Options struct {
+	// Kinds 作为起点的测试类型，为空时包含全部类型
+	Kinds []vs.TestKind
+}
+
+// Report 测试可达性报告，Covered/Total 为被到达的生产函数数和生产函数总数
+type Report struct {
+	Tests    []*TestReach
+	Untested []*FuncRef
+	Covered  int
+	Total    int
+}
+
+// TestReach 单个测试函数到达的生产函数，按函数标识排序
+type TestReach struct {
+	Test    string
+	Kind    vs.TestKind
+	File    string
+	Line    int
+	Reached []*FuncRef
+}
+
+// FuncRef 函数位置，Depth 为距测试函数的调用跳数，未到达的函数为 0
+type FuncRef struct {
+	Func  string
+	File  string
+	Line  int
+	Depth int
+}
+
+// Analyze 计算测试可达性，分析结果需包含 _test.go。与死代码分析相同，调用图未覆盖的调用(反射、函数变量等)会使函数被误报为未测试
+func Analyze(info *service.AstTransverseInfo, options *Options) (*Report, error) {
+	if options == nil {
+		options = &Options{}
+	}
+	graph := query.NewGraph(info)
+	report := &Report{
+		Tests:    make([]*TestReach, 0),
+		Untested: make([]*FuncRef, 0),
+	}
+	covered := make(map[*vs.GoFunc]bool)
+	for _, goFunc := range info.SortedFuncs() {
+		if !goFunc.IsTest() || !matchKind(goFunc.TestKind, options.Kinds) {
+			continue
+		}
+		results, err := graph.Callees(query.IDOf(goFunc), 0)
+		if err != nil {
+			return nil, err
+		}
+		testReach := &TestReach{
+			Test:    query.IDOf(goFunc).String(),
+			Kind:    goFunc.TestKind,
+			File:    goFunc.RFile,
+			Line:    goFunc.Begin.Line,
+			Reached: make([]*FuncRef, 0),
+		}
+		for _, result := range results {
+			if !isProduction(result.Func) {
+				continue
+			}
+			covered[result.Func] = true
+			ref := newFuncRef(result.Func)
+			ref.Depth = result.Depth
+			testReach.Reached = append(testReach.Reached, ref)
+		}
+		sort.Slice(testReach.Reached, func(i, j int) bool {
+			return testReach.Reached[i].Func < testReach.Reached[j].Func
+		})
+		report.Tests = append(report.Tests, testReach)
+	}
+	for _, goFunc := range info.SortedFuncs() {
+		if !isProduction(goFunc) {
+			continue
+		}
+		report.Total++
+		if covered[goFunc] {
+			report.Covered++
+			continue
+		}
+		report.Untested = append(report.Untested, newFuncRef(goFunc))
+	}
+	return report, nil
+}
+
+func newFuncRef(goFunc *vs.GoFunc) *FuncRef {
+	return &FuncRef{
+		Func: query.IDOf(goFunc).String(),
+		File: goFunc.RFile,
+		Line: goFunc.Begin.Line,
+	}
+}
+
+// isProduction 非 _test.go 中声明的函数；init 和包级变量初始化在测试加载包时执行，不单独统计
+func isProduction(goFunc *vs.GoFunc) bool {
+	return !goFunc.IsTestFile() && !strings.HasPrefix(goFunc.Name, "init#")
+}
+
+func matchKind(kind vs.TestKind, kinds []vs.TestKind) bool {
+	if len(kinds) == 0 {
+		return true
+	}
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package testreach
+
+import (
+	"ast-callgraph/service"
+	"ast-callgraph/service/servicetest"
+	"ast-callgraph/vs"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// testreachSource Sum 经测试中的匿名函数调用，memStore.Get 经接口分派到达，Untested 没有测试到达
+var testreachSource = map[string]string{
+	"p/p.go": `package p
+
+type Store interface{ Get() int }
+
+type memStore struct{}
+
+func (memStore) Get() int { return helper() }
+
+func helper() int { return 1 }
+
+func Sum(s Store) int { return s.Get() }
+
+func Fast() int { return 2 }
+
+func Untested() {}
+`,
+	"p/p_test.go": `package p
+
+import "testing"
+
+func TestSum(t *testing.T) {
+	check := func() {
+		if Sum(memStore{}) != 1 {
+			t.Fatal("sum")
+		}
+	}
+	check()
+}
+
+func BenchmarkFast(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Fast()
+	}
+}
+
+func testHelper() { Untested() }
+`,
+}
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  *Options
+		tests    []string
+		untested []string
+		covered  int
+	}{
+		{
+			name:    "all kinds",
+			options: nil,
+			tests: []string{
+				"example.com/m/p.BenchmarkFast Benchmark [example.com/m/p.Fast@1]",
+				"example.com/m/p.TestSum Test [example.com/m/p.Sum@2 example.com/m/p.helper@4 example.com/m/p.memStore.Get@3]",
+			},
+			// 测试文件中的辅助函数不是起点，经它调用的函数不计为被测试到达
+			untested: []string{"example.com/m/p.Untested"},
+			covered:  4,
+		},
+		{
+			name:     "benchmarks only",
+			options:  &Options{Kinds: []vs.TestKind{vs.TestKindBenchmark}},
+			tests:    []string{"example.com/m/p.BenchmarkFast Benchmark [example.com/m/p.Fast@1]"},
+			untested: []string{"example.com/m/p.Sum", "example.com/m/p.Untested", "example.com/m/p.helper", "example.com/m/p.memStore.Get"},
+			covered:  1,
+		},
+	}
+	for _, typeCheck := range []bool{false, true} {
+		info := servicetest.Analyze(t, testreachSource, &service.AstTransverseParam{TypeCheck: typeCheck, IncludeTests: true})
+		for _, tt := range tests {
+			report, err := Analyze(info, tt.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotTests := make([]string, 0)
+			for _, testReach := range report.Tests {
+				reached := make([]string, 0)
+				for _, ref := range testReach.Reached {
+					reached = append(reached, fmt.Sprintf("%s@%d", ref.Func, ref.Depth))
+				}
+				gotTests = append(gotTests, fmt.Sprintf("%s %s %v", testReach.Test, testReach.Kind, reached))
+			}
+			untested := make([]string, 0)
+			for _, ref := range report.Untested {
+				untested = append(untested, ref.Func)
+			}
+			if !reflect.DeepEqual(gotTests, tt.tests) {
+				t.Errorf("typecheck=%v %s: tests = %q, want %q", typeCheck, tt.name, gotTests, tt.tests)
+			}
+			if !reflect.DeepEqual(untested, tt.untested) {
+				t.Errorf("typecheck=%v %s: untested = %q, want %q", typeCheck, tt.name, untested, tt.untested)
+			}
+			if report.Covered != tt.covered || report.Total != 5 {
+				t.Errorf("typecheck=%v %s: covered %d/%d, want %d/5", typeCheck, tt.name, report.Covered, report.Total, tt.covered)
+			}
+		}
+	}
+}
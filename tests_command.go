@@ -0,0 +1,55 @@
+package main
+
+import (
+	"ast-callgraph/testreach"
+	"ast-callgraph/vs"
+	"context"
+	"fmt"
+	"os"
+)
+
+func runTests(ctx context.Context, args []string) error {
+	fs, flags := newFlagSet("tests")
+	perTest := fs.Bool("per-test", false, "list the production funcs reached by each test in text output")
+	var kinds patternsFlag
+	fs.Var(&kinds, "kind", "test kinds used as entrypoints: Test, Benchmark, Fuzz, Example (repeatable or comma separated), all when empty")
+	if err := flags.parse(fs, args); err != nil {
+		return err
+	}
+	options := &testreach.Options{}
+	for _, kind := range kinds {
+		switch testKind := vs.TestKind(kind); testKind {
+		case vs.TestKindTest, vs.TestKindBenchmark, vs.TestKindFuzz, vs.TestKindExample:
+			options.Kinds = append(options.Kinds, testKind)
+		default:
+			return fmt.Errorf("unsupported test kind %q", kind)
+		}
+	}
+	// 测试可达性依赖 _test.go
+	flags.includeTests = true
+	info, err := flags.transverse(ctx)
+	if err != nil {
+		return err
+	}
+	report, err := testreach.Analyze(info, options)
+	if err != nil {
+		return err
+	}
+	if flags.format == formatJson {
+		return writeJson(os.Stdout, report)
+	}
+	fmt.Println("tests:")
+	for _, test := range report.Tests {
+		fmt.Printf("\t%s (%s)\t%s:%d\treaches %d funcs\n", test.Test, test.Kind, test.File, test.Line, len(test.Reached))
+		if *perTest {
+			for _, ref := range test.Reached {
+				fmt.Printf("\t\t%s\t%s:%d\tdepth %d\n", ref.Func, ref.File, ref.Line, ref.Depth)
+			}
+		}
+	}
+	fmt.Printf("untested funcs (%d/%d reached by tests):\n", report.Covered, report.Total)
+	for _, ref := range report.Untested {
+		fmt.Printf("\t%s\t%s:%d\n", ref.Func, ref.File, ref.Line)
+	}
+	return nil
+}
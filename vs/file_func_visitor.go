@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"go/ast"
 	"go/token"
+	"go/types"
+	"path/filepath"
+	"regexp"
 	"strings"
 )
 
@@ -32,25 +35,200 @@ var typeConversions = map[string]struct{}{
 	"uint32": {}, "uint64": {}, "uintptr": {},
 }
 
+var testFuncPattern = regexp.MustCompile(`^(Test|Benchmark|Example|Fuzz)([^a-z].*)?$`)
+
+// TestKind go test 运行的函数类型
+type TestKind string
+
+const (
+	TestKindTest      TestKind = "Test"
+	TestKindBenchmark TestKind = "Benchmark"
+	TestKindFuzz      TestKind = "Fuzz"
+	TestKindExample   TestKind = "Example"
+)
+
+// testParamTypes 测试函数前缀对应的参数类型
+var testParamTypes = map[TestKind]string{
+	TestKindTest:      "*testing.T",
+	TestKindBenchmark: "*testing.B",
+	TestKindFuzz:      "*testing.F",
+}
+
 type FileFuncVisitor struct {
 	FileStructVisitor
 	FuncMap map[string]*GoFunc
+	// TypesInfo 非空时基于类型检查结果精确解析调用，为空时按标识符名称推断
+	TypesInfo *types.Info
+	// Implementers 类型检查模式下返回实现接口的模块内类型，用于展开接口方法调用，为空时不展开
+	Implementers func(iface *types.Interface) []*types.Named
+	// ModulePaths 同一工作区的全部模块路径，语法推断模式下导入这些模块的包调用同样记录，为空时只记录本模块
+	ModulePaths []string
+
+	enclosingFunc      *GoFunc
+	funcLitCount       int
+	globalFuncLitCount int
+	// funcLitNames 匿名函数按先序预先命名，所在函数记录到匿名函数的边时需要名称
+	funcLitNames map[*ast.FuncLit]string
+	// funcLitOuters 匿名函数直接所在的函数，用于查找捕获的外层变量
+	funcLitOuters map[*ast.FuncLit]*GoFunc
 }
 
 type GoFunc struct {
-	Repo        string
-	Pkg         string
-	File        string
-	RFile       string
-	Name        string
-	RecvType    *Var
-	Params      []*Var
-	Results     []*Var
+	Repo    string
+	Pkg     string
+	PkgName string
+	File    string
+	RFile   string
+	// Generated 声明在生成的文件中
+	Generated  bool
+	Name       string
+	RecvType   *Var
+	Params     []*Var
+	Results    []*Var
+	TypeParams []*TypeParam
+	// TestKind _test.go 中由 go test 运行的函数类型，TestMain 记为 Test，非测试函数为空
+	TestKind    TestKind
 	Begin       token.Position
 	End         token.Position
 	Content     string
 	CalleeInfos []*CalleeInfo
 	TmpVars     map[string]*Var
+	// TypeRefs 函数体内复合字面量的类型，如作为参数或返回值直接构造的 &T{}；init#<文件名> 另记包级变量的声明类型
+	TypeRefs []string
+
+	// outer 匿名函数直接所在的函数，不序列化
+	outer *GoFunc
+}
+
+// NewFileFuncVisitor 构造单文件函数访问器，同时采集结构体信息
+func NewFileFuncVisitor(rootPkg, currentPkg, file, rFilePath string, fSet *token.FileSet, content []byte) *FileFuncVisitor {
+	return &FileFuncVisitor{
+		FileStructVisitor: *NewFileStructVisitor(rootPkg, currentPkg, file, rFilePath, fSet, content),
+		FuncMap:           make(map[string]*GoFunc),
+	}
+}
+
+// Key 函数在包内的唯一标识，普通函数为函数名，方法按接收者区分为 Type.Method 或 (*Type).Method
+func (g *GoFunc) Key() string {
+	if g.RecvType == nil {
+		return g.Name
+	}
+	return FuncKey(g.RecvType.Type, g.Name)
+}
+
+// Signature 省略参数名的函数签名，可与 MethodSig.Signature 比较
+func (g *GoFunc) Signature() string {
+	return FormatSignature(varTypes(g.Params), varTypes(g.Results))
+}
+
+func varTypes(vars []*Var) []string {
+	result := make([]string, 0, len(vars))
+	for _, v := range vars {
+		result = append(result, v.Type)
+	}
+	return result
+}
+
+// addTypeRef 记录引用的类型，按首次出现的顺序去重
+func (g *GoFunc) addTypeRef(typeStr string) {
+	for _, typeRef := range g.TypeRefs {
+		if typeRef == typeStr {
+			return
+		}
+	}
+	g.TypeRefs = append(g.TypeRefs, typeStr)
+}
+
+// IsMethod 是否为方法
+func (g *GoFunc) IsMethod() bool {
+	return g.RecvType != nil
+}
+
+// PointerReceiver 是否为指针接收者方法
+func (g *GoFunc) PointerReceiver() bool {
+	return g.RecvType != nil && g.RecvType.IsPointer
+}
+
+// IsTest 是否为 _test.go 中的 Test/Benchmark/Example/Fuzz 函数
+func (g *GoFunc) IsTest() bool {
+	return g.TestKind != ""
+}
+
+// IsTestFile 是否声明在 _test.go 中，含测试辅助函数
+func (g *GoFunc) IsTestFile() bool {
+	return strings.HasSuffix(g.File, "_test.go")
+}
+
+// testKind 按 go test 的规则识别测试函数：名称前缀后不能紧跟小写字母，参数为对应的 *testing 类型，Example 无参数和返回值
+func testKind(goFunc *GoFunc) TestKind {
+	if !goFunc.IsTestFile() || goFunc.RecvType != nil || len(goFunc.TypeParams) > 0 {
+		return ""
+	}
+	match := testFuncPattern.FindStringSubmatch(goFunc.Name)
+	if match == nil {
+		return ""
+	}
+	kind := TestKind(match[1])
+	if kind == TestKindExample {
+		if len(goFunc.Params) == 0 && len(goFunc.Results) == 0 {
+			return kind
+		}
+		return ""
+	}
+	paramType := testParamTypes[kind]
+	if goFunc.Name == "TestMain" {
+		paramType = "*testing.M"
+	}
+	if len(goFunc.Params) == 1 && len(goFunc.Results) == 0 && goFunc.Params[0].Type == paramType {
+		return kind
+	}
+	return ""
+}
+
+// FuncKey 根据接收者完整类型和函数名构造包内唯一标识，接收者为空时返回函数名
+func FuncKey(recvType string, name string) string {
+	if recvType == "" {
+		return name
+	}
+	_, typeName := SplitTypeName(recvType)
+	return MethodKey(typeName, strings.HasPrefix(recvType, "*"), name)
+}
+
+// MethodKey 方法的包内唯一标识，指针接收者为 (*Type).Method，值接收者为 Type.Method
+func MethodKey(typeName string, pointer bool, name string) string {
+	if pointer {
+		return fmt.Sprintf("(*%s).%s", typeName, name)
+	}
+	return fmt.Sprintf(pkgNameFormat, typeName, name)
+}
+
+// AlternateMethodKey 同一方法另一种接收者形式的标识，Type.Method 与 (*Type).Method 互换，非方法标识返回空
+//
+// 同一类型不会同时声明值接收者和指针接收者的同名方法，按变量类型推断的调用可借此忽略取址差异
+func AlternateMethodKey(key string) string {
+	if strings.HasPrefix(key, "(*") {
+		if idx := strings.Index(key, ")."); idx > 0 {
+			return key[2:idx] + key[idx+1:]
+		}
+		return ""
+	}
+	if idx := strings.Index(key, "."); idx > 0 {
+		return "(*" + key[:idx] + ")" + key[idx:]
+	}
+	return ""
+}
+
+// SplitTypeName 将 *pkg/path.Type 形式的完整类型拆分为包路径和类型名，泛型实例化的类型实参被忽略
+func SplitTypeName(fullType string) (pkg string, typeName string) {
+	fullType = strings.TrimPrefix(fullType, "*")
+	if idx := strings.Index(fullType, "["); idx > 0 {
+		fullType = fullType[:idx]
+	}
+	idx := strings.LastIndex(fullType, ".")
+	if idx < 0 {
+		return "", fullType
+	}
+	return fullType[:idx], fullType[idx+1:]
 }
 
 type CalleeInfo struct {
@@ -60,45 +238,188 @@ type CalleeInfo struct {
 	Begin    token.Position
 	End      token.Position
 	Receiver *string
+	// Dynamic 通过接口动态分派展开得到的调用
+	Dynamic bool
+	// Reference 函数作为值被引用而非直接调用，如回调注册、函数表
+	Reference bool
+	// TypeArgs 泛型函数或泛型类型方法调用的类型实参，按完整包名展开
+	TypeArgs []string
+	// Selectors 语法推断模式下接收者变量之后的字段访问路径，如 s.repo.Get() 为 [repo]
+	Selectors []string
 }
 
 func (f *FileFuncVisitor) Visit(node ast.Node) ast.Visitor {
 	var recvField *ast.FieldList
 	var funcType *ast.FuncType
 	goFunc := &GoFunc{
-		Repo:    f.RootPkg,
-		Pkg:     f.CurrentPkg,
-		File:    f.File,
-		TmpVars: make(map[string]*Var),
+		Repo:      f.RootPkg,
+		Pkg:       f.CurrentPkg,
+		PkgName:   f.PkgName,
+		File:      f.File,
+		RFile:     f.RFilePath,
+		Generated: f.Generated,
+		TmpVars:   make(map[string]*Var),
 	}
 	switch n := node.(type) {
-	case *ast.GenDecl, *ast.TypeSpec:
+	case *ast.File:
+		f.FileStructVisitor.Visit(n)
+	case *ast.GenDecl:
+		// 局部变量由 handleFuncVarDecl 记录到 TmpVars
+		if n.Tok == token.VAR && !f.isPackageLevel(n) {
+			return f
+		}
+		if f.isPackageLevel(n) {
+			f.setTypeParams()
+			if n.Tok == token.VAR {
+				f.collectPackageVarInit(n)
+			}
+		}
+		return f.FileStructVisitor.Visit(n)
+	case *ast.TypeSpec:
 		return f.FileStructVisitor.Visit(n)
 	case *ast.FuncDecl:
+		funcType = n.Type
+		recvField = n.Recv
 		goFunc.Name = n.Name.Name
+		goFunc.Begin = f.FSet.Position(n.Pos())
+		goFunc.End = f.endPosition(n)
 		if goFunc.Name == "init" {
-			goFunc.Name = fmt.Sprintf("init#%d", "xxx")
+			// 同一个包内允许多个init，按文件和行号区分
+			goFunc.Name = fmt.Sprintf("init#%s:%d", filepath.Base(f.File), goFunc.Begin.Line)
 		}
-		goFunc.Begin = f.FSet.Position(n.Pos())
-		goFunc.End = f.FSet.Position(n.End())
+		f.enclosingFunc = goFunc
+		f.funcLitCount = 0
+		// 类型参数作用域持续到下一个顶层声明，覆盖函数体内的匿名函数
+		f.setTypeParams(receiverTypeParams(n.Recv)...)
+		goFunc.TypeParams = f.collectTypeParams(n.Type.TypeParams)
+		f.setTypeParams(append(receiverTypeParams(n.Recv), typeParamNames(goFunc.TypeParams)...)...)
 		f.CollectFuncBasicInfo(goFunc, funcType, recvField)
+		goFunc.TestKind = testKind(goFunc)
+		// 语法错误时函数体可能缺失，*ast.BlockStmt 为 nil 时不能作为 ast.Node 遍历
+		if n.Body != nil {
+			f.nameFuncLits(n.Body, func() string {
+				f.funcLitCount++
+				return fmt.Sprintf("%s$%d", goFunc.Key(), f.funcLitCount)
+			})
+		}
 		f.CollectFuncBodyCaller(goFunc, n.Body)
 	case *ast.FuncLit:
 		funcType = n.Type
 		goFunc.Begin = f.FSet.Position(n.Pos())
-		goFunc.End = f.FSet.Position(n.End())
+		goFunc.End = f.endPosition(n)
+		goFunc.Name = f.funcLitName(n)
+		goFunc.outer = f.funcLitOuters[n]
 		f.CollectFuncBasicInfo(goFunc, funcType, recvField)
 		f.CollectFuncBodyCaller(goFunc, n.Body)
-
 	}
 	return f
 }
 
+// isPackageLevel 节点是否位于函数体之外，FuncDecl 不会嵌套，按最近一个函数的结束位置判断
+func (f *FileFuncVisitor) isPackageLevel(node ast.Node) bool {
+	return f.enclosingFunc == nil || f.FSet.Position(node.Pos()).Offset > f.enclosingFunc.End.Offset
+}
+
+// collectPackageVarInit 包级变量初始化表达式中的调用和函数引用归到本文件的 init#<文件名> 函数下。
+// 初始化表达式在程序启动时执行，不挂到某个函数下时 var x = newX() 中的 newX 在可达性分析中没有调用方
+func (f *FileFuncVisitor) collectPackageVarInit(decl *ast.GenDecl) {
+	name := fmt.Sprintf("init#%s", filepath.Base(f.File))
+	goFunc, ok := f.FuncMap[name]
+	if !ok {
+		goFunc = &GoFunc{
+			Repo:      f.RootPkg,
+			Pkg:       f.CurrentPkg,
+			PkgName:   f.PkgName,
+			File:      f.File,
+			RFile:     f.RFilePath,
+			Generated: f.Generated,
+			Name:      name,
+			Begin:     f.FSet.Position(decl.Pos()),
+			TmpVars:   make(map[string]*Var),
+		}
+		f.FuncMap[name] = goFunc
+	}
+	goFunc.End = f.endPosition(decl)
+	for _, spec := range decl.Specs {
+		if valueSpec, ok := spec.(*ast.ValueSpec); ok {
+			if valueSpec.Type != nil {
+				goFunc.addTypeRef(f.typeString(valueSpec.Type, false))
+			}
+			for _, value := range valueSpec.Values {
+				f.nameFuncLits(value, func() string {
+					f.globalFuncLitCount++
+					return fmt.Sprintf("init$%d", f.globalFuncLitCount)
+				})
+				f.collectNodeCaller(goFunc, value)
+				// 包级声明由结构体访问器遍历，其中的匿名函数在此单独遍历，所在函数视为 init#<文件名>
+				previous := f.enclosingFunc
+				f.enclosingFunc = goFunc
+				ast.Walk(f, value)
+				f.enclosingFunc = previous
+			}
+		}
+	}
+}
+
+// nameFuncLits 按先序为节点内的匿名函数命名，嵌套的匿名函数同样按所在顶层函数编号，如 main$1、main$2
+func (f *FileFuncVisitor) nameFuncLits(node ast.Node, name func() string) {
+	if node == nil {
+		return
+	}
+	if f.funcLitNames == nil {
+		f.funcLitNames = make(map[*ast.FuncLit]string)
+	}
+	ast.Inspect(node, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.FuncLit); ok {
+			f.funcLitNames[lit] = name()
+		}
+		return true
+	})
+}
+
+// funcLitName 匿名函数按所在函数命名，如 main$1，包级匿名函数挂在 init 下
+func (f *FileFuncVisitor) funcLitName(lit *ast.FuncLit) string {
+	packageLevel := f.isPackageLevel(lit)
+	if packageLevel {
+		f.enclosingFunc = nil
+		f.setTypeParams()
+	}
+	if name, ok := f.funcLitNames[lit]; ok {
+		return name
+	}
+	if packageLevel {
+		f.globalFuncLitCount++
+		return fmt.Sprintf("init$%d", f.globalFuncLitCount)
+	}
+	f.funcLitCount++
+	return fmt.Sprintf("%s$%d", f.enclosingFunc.Key(), f.funcLitCount)
+}
+
+// appendFuncLitCallee 记录所在函数到匿名函数的引用边，匿名函数体内的调用只归属匿名函数本身
+func (f *FileFuncVisitor) appendFuncLitCallee(lit *ast.FuncLit, goFunc *GoFunc) {
+	name, ok := f.funcLitNames[lit]
+	if !ok {
+		return
+	}
+	if f.funcLitOuters == nil {
+		f.funcLitOuters = make(map[*ast.FuncLit]*GoFunc)
+	}
+	f.funcLitOuters[lit] = goFunc
+	goFunc.CalleeInfos = append(goFunc.CalleeInfos, &CalleeInfo{
+		Pkg:       goFunc.Pkg,
+		File:      goFunc.RFile,
+		Name:      name,
+		Begin:     f.FSet.Position(lit.Type.Pos()),
+		End:       f.endPosition(lit.Type),
+		Reference: true,
+	})
+}
+
 func (f *FileFuncVisitor) CollectFuncBasicInfo(goFunc *GoFunc, funcType *ast.FuncType, recvField *ast.FieldList) {
 	if funcType == nil {
 		return
 	}
-	if recvField == nil {
+	if recvField != nil {
 		f.handleFieldList(recvField.List, func(v *Var) {
 			goFunc.RecvType = v
 		}, true)
@@ -118,39 +439,15 @@ func (f *FileFuncVisitor) CollectFuncBasicInfo(goFunc *GoFunc, funcType *ast.Fun
 			goFunc.Results = append(goFunc.Results, v)
 		}, false)
 	}
-	f.FuncMap[goFunc.Name] = goFunc
+	f.FuncMap[goFunc.Key()] = goFunc
 }
 
 func (f *FileFuncVisitor) handleFieldList(list []*ast.Field, handle func(v *Var), isRecv bool) {
 	for _, field := range list {
-		var typeStr string
-		switch t := field.Type.(type) {
-		case *ast.Ident, *ast.SelectorExpr:
-			shortPkg, name := parseSimpleExpr(t, true)
-			typeName := f.getFullTypeName(shortPkg, name, isRecv)
-			typeStr = typeName
-		case *ast.StarExpr:
-			shortPkg, name := parseStarExprIfNeed(t.X, true)
-			typeName := f.getFullTypeName(shortPkg, name, isRecv)
-			typeStr = "*" + typeName
-		case *ast.ArrayType:
-			shortPkg, name := parseStarExprIfNeed(t.Elt, true)
-			typeName := f.getFullTypeName(shortPkg, name, isRecv)
-			typeStr = "[]" + typeName
-		case *ast.MapType:
-			shortPkg, name := parseStarExprIfNeed(t.Value, true)
-			typeName := f.getFullTypeName(shortPkg, name, isRecv)
-			if ident, ok := t.Key.(*ast.Ident); ok {
-				typeStr = fmt.Sprintf("map[%s]%s", ident.Name, typeName)
-			} else {
-				typeStr = fmt.Sprintf("map[]%s", typeName)
-			}
-		default:
-			typeStr = "unknown"
-		}
+		typeStr := f.typeString(field.Type, isRecv)
 		isPointer := strings.HasPrefix(typeStr, "*")
 		startPos := f.FSet.Position(field.Pos()).Offset
-		endPos := f.FSet.Position(field.End()).Offset
+		endPos := f.endPosition(field).Offset
 		if len(field.Names) > 0 {
 			for _, name := range field.Names {
 				v := &Var{
@@ -179,33 +476,97 @@ func (f *FileFuncVisitor) CollectFuncBodyCaller(goFunc *GoFunc, body *ast.BlockS
 	if goFunc == nil || body == nil {
 		return
 	}
-	ast.Inspect(body, func(nx ast.Node) bool {
+	f.collectNodeCaller(goFunc, body)
+}
+
+// collectNodeCaller 采集节点内的调用，类型检查模式下非调用位置的函数引用同样记录；匿名函数只记录到它的引用边，函数体单独采集
+func (f *FileFuncVisitor) collectNodeCaller(goFunc *GoFunc, node ast.Node) {
+	callFuns := make(map[*ast.Ident]struct{})
+	ast.Inspect(node, func(nx ast.Node) bool {
+		if lit, ok := nx.(*ast.FuncLit); ok {
+			f.appendFuncLitCallee(lit, goFunc)
+			return false
+		}
 		if callExpr, ok := nx.(*ast.CallExpr); ok {
 			// 1.函数调用
 			f.handleCallExpr(callExpr, goFunc)
+			if ident := calleeIdent(callExpr.Fun); ident != nil {
+				callFuns[ident] = struct{}{}
+			}
+		} else if ident, ok := nx.(*ast.Ident); ok && f.TypesInfo != nil {
+			// 1.函数值引用，如注册回调、函数表
+			if _, ok := callFuns[ident]; !ok {
+				f.handleTypedReference(ident, goFunc)
+			}
 		} else if assignStmt, ok := nx.(*ast.AssignStmt); ok {
 			// 1.函数内局部变量赋值语句
 			f.handleFuncVarsAssign(assignStmt, goFunc)
 		} else if decl, ok := nx.(*ast.GenDecl); ok && decl.Tok == token.VAR {
 			// 1.函数内局部变量声明
 			f.handleFuncVarDecl(decl, goFunc)
+		} else if lit, ok := nx.(*ast.CompositeLit); ok && lit.Type != nil {
+			// 1.复合字面量构造的类型，省略类型的内层字面量由外层类型覆盖
+			goFunc.addTypeRef(f.typeString(lit.Type, false))
 		}
 		return true
 	})
 }
 
 func (f *FileFuncVisitor) handleCallExpr(expr *ast.CallExpr, goFunc *GoFunc) {
-	if selExpr, ok := expr.Fun.(*ast.SelectorExpr); ok {
+	if f.TypesInfo != nil {
+		f.handleTypedCallExpr(expr, goFunc)
+		return
+	}
+	// 泛型函数显式实例化 F[T]() 记录类型实参
+	fun, typeArgs := f.instantiatedFunc(expr.Fun)
+	calleeCnt := len(goFunc.CalleeInfos)
+	if selExpr, ok := fun.(*ast.SelectorExpr); ok {
 		// 选择器调用
 		f.handleSelectorExprCall(selExpr, goFunc)
-	} else if ident, ok := expr.Fun.(*ast.Ident); ok {
+	} else if ident, ok := fun.(*ast.Ident); ok {
 		// 函数名调用
 		f.handleIdentCall(ident, goFunc)
 	}
+	for _, info := range goFunc.CalleeInfos[calleeCnt:] {
+		info.TypeArgs = typeArgs
+	}
 	// 函数参数调用采集
 	f.handleFuncArgsCall(expr, goFunc)
 }
 
+// instantiatedFunc 拆分泛型函数实例化表达式，返回被调函数和类型实参；函数切片下标等非实例化表达式原样返回
+func (f *FileFuncVisitor) instantiatedFunc(fun ast.Expr) (ast.Expr, []string) {
+	var x ast.Expr
+	var indices []ast.Expr
+	switch e := fun.(type) {
+	case *ast.IndexExpr:
+		x, indices = e.X, []ast.Expr{e.Index}
+	case *ast.IndexListExpr:
+		x, indices = e.X, e.Indices
+	default:
+		return fun, nil
+	}
+	switch e := x.(type) {
+	case *ast.Ident:
+		// 本文件声明的变量按下标访问处理
+		if e.Obj != nil && e.Obj.Kind != ast.Fun {
+			return fun, nil
+		}
+	case *ast.SelectorExpr:
+		// 只有包级泛型函数可以显式实例化，方法不能声明类型参数
+		if ident, ok := e.X.(*ast.Ident); !ok || f.ImportedPkgMap[ident.Name] == "" {
+			return fun, nil
+		}
+	default:
+		return fun, nil
+	}
+	typeArgs := make([]string, 0, len(indices))
+	for _, index := range indices {
+		typeArgs = append(typeArgs, f.typeString(index, false))
+	}
+	return x, typeArgs
+}
+
 func (f *FileFuncVisitor) handleFuncArgsCall(callExpr *ast.CallExpr, goFunc *GoFunc) {
 	for _, arg := range callExpr.Args {
 		if ident, ok := arg.(*ast.Ident); ok {
@@ -232,8 +593,9 @@ func (f *FileFuncVisitor) handleIdentCall(ident *ast.Ident, goFunc *GoFunc) {
 				goFunc.CalleeInfos = append(goFunc.CalleeInfos, &CalleeInfo{
 					Pkg:   goFunc.Pkg,
 					File:  goFunc.RFile,
+					Name:  identName,
 					Begin: f.FSet.Position(ident.Pos()),
-					End:   f.FSet.Position(ident.End()),
+					End:   f.endPosition(ident),
 				})
 			}
 		}
@@ -241,98 +603,152 @@ func (f *FileFuncVisitor) handleIdentCall(ident *ast.Ident, goFunc *GoFunc) {
 }
 
 func (f *FileFuncVisitor) handleSelectorExprCall(selExpr *ast.SelectorExpr, goFunc *GoFunc) {
-	if ident, ok := selExpr.X.(*ast.Ident); ok {
-		shortPkgName := ident.Name
-		if goFunc.RecvType != nil && goFunc.RecvType.Name == shortPkgName {
-			info := &CalleeInfo{
-				Pkg:   goFunc.Pkg,
-				File:  goFunc.RFile,
-				Name:  selExpr.Sel.Name,
-				Begin: f.FSet.Position(ident.Pos()),
-				End:   f.FSet.Position(ident.End()),
-			}
-			if goFunc.RecvType.Type != "" {
-				info.Receiver = &goFunc.RecvType.Type
-			}
-			goFunc.CalleeInfos = append(goFunc.CalleeInfos, info)
-		} else if pkgInfo, ok := f.ImportedPkgMap[shortPkgName]; ok {
-			if strings.HasPrefix(pkgInfo, f.RootPkg) {
-				goFunc.CalleeInfos = append(goFunc.CalleeInfos, &CalleeInfo{
-					Pkg:   pkgInfo,
-					File:  goFunc.RFile,
-					Name:  selExpr.Sel.Name,
-					Begin: f.FSet.Position(ident.Pos()),
-					End:   f.FSet.Position(ident.End()),
-				})
-			}
-		} else if pkgVar, ok := f.VarMap[shortPkgName]; ok {
+	ident, selectors := selectorChain(selExpr.X)
+	if ident == nil {
+		return
+	}
+	shortPkgName := ident.Name
+	if v := f.lookupVar(goFunc, shortPkgName); v != nil {
+		// 变量方法调用，含经字段访问的 v.field.Method()
+		f.appendVarMethodCall(v, selectors, selExpr, goFunc)
+	} else if pkgInfo, ok := f.ImportedPkgMap[shortPkgName]; ok && len(selectors) == 0 {
+		if f.isModulePkg(pkgInfo) {
 			goFunc.CalleeInfos = append(goFunc.CalleeInfos, &CalleeInfo{
-				Pkg:   pkgVar.Type,
+				Pkg:   pkgInfo,
 				File:  goFunc.RFile,
 				Name:  selExpr.Sel.Name,
 				Begin: f.FSet.Position(ident.Pos()),
-				End:   f.FSet.Position(ident.End()),
+				End:   f.endPosition(ident),
 			})
-		} else {
-			for _, param := range goFunc.Params {
-				if param.Name == shortPkgName {
-					goFunc.CalleeInfos = append(goFunc.CalleeInfos, &CalleeInfo{
-						Pkg:   goFunc.Pkg,
-						File:  goFunc.RFile,
-						Name:  selExpr.Sel.Name,
-						Begin: f.FSet.Position(ident.Pos()),
-						End:   f.FSet.Position(ident.End()),
-					})
-				}
-			}
 		}
 	}
 }
 
+// isModulePkg 包是否属于本模块或同一工作区的其他模块
+func (f *FileFuncVisitor) isModulePkg(pkg string) bool {
+	if InModule(pkg, f.RootPkg) {
+		return true
+	}
+	for _, modPath := range f.ModulePaths {
+		if InModule(pkg, modPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// InModule 包路径是否为模块路径本身或位于其下
+func InModule(pkg string, modPath string) bool {
+	return pkg == modPath || strings.HasPrefix(pkg, modPath+"/")
+}
+
+// selectorChain 拆分 v.f1.f2 形式的表达式为根标识符和字段路径，其他表达式返回 nil
+func selectorChain(expr ast.Expr) (*ast.Ident, []string) {
+	switch e := ast.Unparen(expr).(type) {
+	case *ast.Ident:
+		return e, nil
+	case *ast.SelectorExpr:
+		ident, selectors := selectorChain(e.X)
+		if ident == nil {
+			return nil, nil
+		}
+		return ident, append(selectors, e.Sel.Name)
+	}
+	return nil, nil
+}
+
+// lookupVar 按作用域由内到外查找变量：接收者、局部变量、参数，匿名函数继续查找捕获的外层函数变量，最后是包级变量
+func (f *FileFuncVisitor) lookupVar(goFunc *GoFunc, name string) *Var {
+	for scope := goFunc; scope != nil; scope = scope.outer {
+		if scope.RecvType != nil && scope.RecvType.Name == name {
+			return scope.RecvType
+		}
+		if tmpVar, ok := scope.TmpVars[name]; ok {
+			return tmpVar
+		}
+		if param := findVar(scope.Params, name); param != nil {
+			return param
+		}
+	}
+	if pkgVar, ok := f.VarMap[name]; ok {
+		return pkgVar
+	}
+	return nil
+}
+
+// appendVarMethodCall 记录通过变量调用的方法，接收者类型取变量声明类型，经字段访问时记录字段路径由 service 解析
+func (f *FileFuncVisitor) appendVarMethodCall(v *Var, selectors []string, selExpr *ast.SelectorExpr, goFunc *GoFunc) {
+	pkg, typeName := SplitTypeName(v.Type)
+	// 基础类型、切片和map等无法定位方法归属
+	if pkg == "" || typeName == "" || strings.ContainsAny(pkg, "[]") {
+		return
+	}
+	recvType := v.Type
+	goFunc.CalleeInfos = append(goFunc.CalleeInfos, &CalleeInfo{
+		Pkg:       pkg,
+		File:      goFunc.RFile,
+		Name:      selExpr.Sel.Name,
+		Begin:     f.FSet.Position(selExpr.X.Pos()),
+		End:       f.endPosition(selExpr.X),
+		Receiver:  &recvType,
+		Selectors: selectors,
+	})
+}
+
+func findVar(vars []*Var, name string) *Var {
+	for _, v := range vars {
+		if v.Name == name {
+			return v
+		}
+	}
+	return nil
+}
+
 func (f *FileFuncVisitor) handleFuncVarDecl(decl *ast.GenDecl, goFunc *GoFunc) {
 	for _, spec := range decl.Specs {
 		if valueSpec, ok := spec.(*ast.ValueSpec); ok {
-			shortPkgName := ""
-			typeName := ""
-			if ident, ok := valueSpec.Type.(*ast.Ident); ok {
-				typeName = ident.Name
-			} else if selectorExpr, ok := valueSpec.Type.(*ast.SelectorExpr); ok {
-				shortPkgName = selectorExpr.X.(*ast.Ident).Name
-				typeName = selectorExpr.Sel.Name
-			}
-			for _, name := range valueSpec.Names {
+			for i, name := range valueSpec.Names {
+				typeStr := unknownType
+				if valueSpec.Type != nil {
+					typeStr = f.typeString(valueSpec.Type, false)
+				} else if len(valueSpec.Values) == len(valueSpec.Names) {
+					if valueType := f.valueTypeString(valueSpec.Values[i]); valueType != "" {
+						typeStr = valueType
+					}
+				}
 				goFunc.TmpVars[name.Name] = &Var{
-					Name:   name.Name,
-					Type:   f.getFullTypeName(shortPkgName, typeName, false),
-					NoName: false,
+					Name:      name.Name,
+					Type:      typeStr,
+					NoName:    false,
+					IsPointer: strings.HasPrefix(typeStr, "*"),
 				}
 			}
 		}
 	}
 }
 
+// handleFuncVarsAssign 局部变量赋值，类型无法从右值直接确定的新变量记为 unknown，避免按同名包或全局变量误解析
 func (f *FileFuncVisitor) handleFuncVarsAssign(stmt *ast.AssignStmt, goFunc *GoFunc) {
-	var shortPkgName, typeName string
-	if ident, ok := stmt.Rhs[0].(*ast.CompositeLit); ok {
-		if a, ok := ident.Type.(*ast.Ident); ok {
-			typeName = a.Name
-		} else if a, ok := ident.Type.(*ast.SelectorExpr); ok {
-			shortPkgName = a.X.(*ast.Ident).Name
-			typeName = a.Sel.Name
+	for i, lh := range stmt.Lhs {
+		ident, ok := lh.(*ast.Ident)
+		if !ok || ident.Name == "_" {
+			continue
 		}
-	} else if selectorExpr, ok := stmt.Rhs[0].(*ast.SelectorExpr); ok {
-		if a, ok := selectorExpr.X.(*ast.Ident); ok {
-			shortPkgName = a.Name
-			typeName = selectorExpr.Sel.Name
+		typeStr := ""
+		if len(stmt.Rhs) == len(stmt.Lhs) {
+			typeStr = f.valueTypeString(stmt.Rhs[i])
 		}
-	}
-	for _, lh := range stmt.Lhs {
-		if ident, ok := lh.(*ast.Ident); ok {
-			goFunc.TmpVars[ident.Name] = &Var{
-				Name:   ident.Name,
-				Type:   f.getFullTypeName(shortPkgName, typeName, false),
-				NoName: false,
+		if typeStr == "" {
+			if stmt.Tok != token.DEFINE {
+				continue
 			}
+			typeStr = unknownType
+		}
+		goFunc.TmpVars[ident.Name] = &Var{
+			Name:      ident.Name,
+			Type:      typeStr,
+			NoName:    false,
+			IsPointer: strings.HasPrefix(typeStr, "*"),
 		}
 	}
 }
@@ -0,0 +1,83 @@
+package vs
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// TestVisitPartialAST 语法错误产生的部分 AST 中结束位置越过文件末尾、函数体为空，访问器不 panic 并保留已采集的声明，源码截止到文件末尾
+func TestVisitPartialAST(t *testing.T) {
+	tests := []struct {
+		name       string
+		src        string
+		wantTypes  []string
+		wantFuncs  []string
+		wantSource string
+	}{
+		{
+			name:       "unterminated struct",
+			src:        "package p\n\ntype S struct {\n\tA int\n",
+			wantTypes:  []string{"S"},
+			wantFuncs:  []string{},
+			wantSource: "type S struct {\n\tA int",
+		},
+		{
+			name:       "missing body and unterminated interface",
+			src:        "package p\n\nfunc NoBody()\n\nfunc (s *S) M() { helper(func() {}) \n\ntype I interface {\n\tM(\n",
+			wantTypes:  []string{"I"},
+			wantFuncs:  []string{"(*S).M", "(*S).M$1", "NoBody"},
+			wantSource: "type I interface {\n\tM(",
+		},
+	}
+	for _, tt := range tests {
+		fset := token.NewFileSet()
+		astFile, err := parser.ParseFile(fset, "p.go", tt.src, parser.ParseComments)
+		if err == nil {
+			t.Errorf("%s: want syntax error", tt.name)
+			continue
+		}
+		visitor := NewFileFuncVisitor("example.com/m", "example.com/m/p", "/m/p/p.go", "p/p.go", fset, []byte(tt.src))
+		ast.Walk(visitor, astFile)
+		types, sources := make([]string, 0), make([]string, 0)
+		for _, structInfo := range visitor.StructInfoMap["example.com/m/p"] {
+			types, sources = append(types, structInfo.Name), append(sources, structInfo.Content)
+		}
+		for _, interfaceInfo := range visitor.InterfaceInfoMap["example.com/m/p"] {
+			types, sources = append(types, interfaceInfo.Name), append(sources, interfaceInfo.Content)
+		}
+		funcs := make([]string, 0, len(visitor.FuncMap))
+		for key := range visitor.FuncMap {
+			funcs = append(funcs, key)
+		}
+		sort.Strings(funcs)
+		if !reflect.DeepEqual(types, tt.wantTypes) || !reflect.DeepEqual(sources, []string{tt.wantSource}) {
+			t.Errorf("%s: types = %v %q, want %v %q", tt.name, types, sources, tt.wantTypes, tt.wantSource)
+		}
+		if !reflect.DeepEqual(funcs, tt.wantFuncs) {
+			t.Errorf("%s: funcs = %v, want %v", tt.name, funcs, tt.wantFuncs)
+		}
+	}
+}
+
+// TestVisitSharedFileSet 多个文件共用 FileSet 时，越过文件末尾的结束位置不落到相邻文件
+func TestVisitSharedFileSet(t *testing.T) {
+	fset := token.NewFileSet()
+	src := "package p\n\nfunc Broken( {\n"
+	astFile, _ := parser.ParseFile(fset, "/m/p/bad.go", src, parser.ParseComments)
+	if _, err := parser.ParseFile(fset, "/m/p/next.go", "package p\n", 0); err != nil {
+		t.Fatal(err)
+	}
+	visitor := NewFileFuncVisitor("example.com/m", "example.com/m/p", "/m/p/bad.go", "p/bad.go", fset, []byte(src))
+	ast.Walk(visitor, astFile)
+	goFunc := visitor.FuncMap["Broken"]
+	if goFunc == nil {
+		t.Fatal("Broken not found")
+	}
+	if goFunc.End.Filename != "/m/p/bad.go" || goFunc.End.Offset != len(src) {
+		t.Errorf("Broken end = %v, want end of /m/p/bad.go", goFunc.End)
+	}
+}
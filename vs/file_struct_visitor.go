@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"go/ast"
 	"go/token"
+	"strconv"
 	"strings"
 )
 
@@ -12,15 +13,26 @@ const (
 )
 
 type FileStructVisitor struct {
-	RootPkg        string
-	CurrentPkg     string
-	FSet           token.FileSet
+	RootPkg    string
+	CurrentPkg string
+	// PkgName 文件 package 子句声明的包名，与 CurrentPkg 的最后一段不一定相同
+	PkgName string
+	// Generated 文件带有 // Code generated ... DO NOT EDIT. 标记
+	Generated bool
+	// PkgNames 导入路径 -> 声明的包名，用于识别包名与目录名不同且未加别名的导入，缺失时按导入路径推断
+	PkgNames       map[string]string
+	FSet           *token.FileSet
 	File           string
 	RFilePath      string
 	RawContent     []string
 	ImportedPkgMap map[string]string
 	StructInfoMap  map[string][]*StructInfo
-	VarMap         map[string]*Var
+	// InterfaceInfoMap 包名 -> 接口定义
+	InterfaceInfoMap map[string][]*InterfaceInfo
+	VarMap           map[string]*Var
+
+	// typeParams 当前所在泛型声明的类型参数名
+	typeParams map[string]struct{}
 }
 
 type Var struct {
@@ -35,24 +47,118 @@ type Var struct {
 }
 
 type StructInfo struct {
-	Repo           string
-	Pkg            string
-	File           string
+	Repo    string
+	Pkg     string
+	PkgName string
+	File    string
+	// Generated 声明在生成的文件中
+	Generated      bool
 	Name           string
 	TypeName       string
 	StartLine      int
 	EndLine        int
 	Content        string
+	TypeParams     []*TypeParam
+	Fields         []*Field
 	DepsStructInfo map[string]map[string]StructIndex
+	// Methods 以该类型为接收者声明的方法，按方法名排序，跨文件合并后由 service 关联
+	Methods []MethodIndex
+	// PromotedFields 经嵌入链提升的字段，同一深度重名的字段有歧义，不提升
+	PromotedFields []PromotedField
+	// PromotedMethods 经嵌入链提升的模块内方法，被自身方法或更浅的字段遮蔽的不提升
+	PromotedMethods []PromotedMethod
 }
 
 type StructIndex struct {
 	Pkg  string
 	Name string
+	// TypeName 完整类型名 pkg/path.Name，与 Var.Type 中的命名类型写法一致
+	TypeName string
+	// Embedded 依赖来自嵌入字段的类型本身，不含其类型实参
+	Embedded bool
+}
+
+// Field 结构体字段，嵌入字段的 Name 为类型名，Tag 为去掉反引号的原始标签，Tags 为解析后的键值对
+type Field struct {
+	Name     string
+	Type     string
+	Embedded bool
+	Tag      string
+	Tags     map[string]string
+	// Doc 字段上方的文档注释，Comment 为同行尾注释
+	Doc     string
+	Comment string
+	Pos     token.Position
+}
+
+// PromotedField 提升字段，Via 为从外层到声明该字段的结构体经过的嵌入字段名
+type PromotedField struct {
+	Name string
+	Type string
+	Via  []string
+}
+
+// PromotedMethod 提升方法，Pkg 和 Key 定位 FuncInfoMap 中的方法，Via 含义同 PromotedField
+type PromotedMethod struct {
+	Name            string
+	Pkg             string
+	Key             string
+	PointerReceiver bool
+	Via             []string
+}
+
+// InterfaceInfo 接口定义，Methods 为直接声明的方法，Embeds 为嵌入的接口或类型约束
+type InterfaceInfo struct {
+	Repo    string
+	Pkg     string
+	PkgName string
+	File    string
+	// Generated 声明在生成的文件中
+	Generated  bool
+	Name       string
+	TypeName   string
+	StartLine  int
+	EndLine    int
+	Content    string
+	TypeParams []*TypeParam
+	Methods    []*MethodSig
+	Embeds     []string
+}
+
+// MethodSig 接口方法签名，Signature 为省略参数名的 (params) results 形式
+type MethodSig struct {
+	Name      string
+	Signature string
+}
+
+// MethodIndex 方法集中的方法，Key 为 FuncInfoMap 中的包内标识
+type MethodIndex struct {
+	Name            string
+	Key             string
+	PointerReceiver bool
+}
+
+// NewFileStructVisitor 构造单文件结构体访问器
+func NewFileStructVisitor(rootPkg, currentPkg, file, rFilePath string, fSet *token.FileSet, content []byte) *FileStructVisitor {
+	return &FileStructVisitor{
+		RootPkg:          rootPkg,
+		CurrentPkg:       currentPkg,
+		FSet:             fSet,
+		File:             file,
+		RFilePath:        rFilePath,
+		RawContent:       strings.Split(string(content), "\n"),
+		ImportedPkgMap:   make(map[string]string),
+		StructInfoMap:    make(map[string][]*StructInfo),
+		InterfaceInfoMap: make(map[string][]*InterfaceInfo),
+		VarMap:           make(map[string]*Var),
+	}
 }
 
 func (f *FileStructVisitor) Visit(node ast.Node) ast.Visitor {
 	switch n := node.(type) {
+	case *ast.File:
+		f.PkgName = n.Name.Name
+		f.Generated = ast.IsGenerated(n)
 	case *ast.GenDecl:
 		for _, spec := range n.Specs {
 			if importSpec, ok := spec.(*ast.ImportSpec); ok {
@@ -74,99 +180,296 @@ func (f *FileStructVisitor) CollectFileImportPkg(spec *ast.ImportSpec) {
 	// 导入别名
 	if spec.Name != nil {
 		pkgName = spec.Name.Name
+	} else if name, ok := f.PkgNames[pkgPath]; ok {
+		// 模块内的包使用声明的包名
+		pkgName = name
 	} else {
-		// 系统包还是外部包
-		if strings.Contains(pkgPath, "/") {
-			pkgName = pkgPath[strings.LastIndex(pkgPath, "/")+1:]
-		} else {
-			pkgName = pkgPath
-		}
+		pkgName = AssumedPkgName(pkgPath)
 	}
 	f.ImportedPkgMap[pkgName] = pkgPath
 }
 
-// CollectFileGlobalPkgVars 采集文件包名结构体数据
+// AssumedPkgName 按导入路径推断包名：取最后一段，跳过主版本后缀 /vN，去掉 gopkg.in 的 .vN、go- 前缀和 -go 后缀
+func AssumedPkgName(pkgPath string) string {
+	parts := strings.Split(pkgPath, "/")
+	name := parts[len(parts)-1]
+	if len(parts) > 1 && IsMajorVersion(name) {
+		name = parts[len(parts)-2]
+	}
+	if idx := strings.Index(name, ".v"); idx > 0 && IsMajorVersion(name[idx+1:]) {
+		name = name[:idx]
+	}
+	name = strings.TrimPrefix(name, "go-")
+	name = strings.TrimSuffix(name, "-go")
+	return name
+}
+
+// IsMajorVersion 是否为 v2、v3 这样的主版本后缀，v0 及 v01 等前导零形式不是合法版本
+func IsMajorVersion(s string) bool {
+	if len(s) < 2 || s[0] != 'v' || s[1] == '0' {
+		return false
+	}
+	for _, c := range s[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// CollectFileGlobalPkgVars 采集文件包名结构体数据，类型取声明类型或复合字面量类型
 func (f *FileStructVisitor) CollectFileGlobalPkgVars(spec *ast.ValueSpec) {
-	for _, name := range spec.Names {
-		if name.Obj != nil && name.Obj.Decl != nil {
-			if valueSpec, ok := name.Obj.Decl.(*ast.ValueSpec); ok && valueSpec.Values != nil {
-				if lit, ok := valueSpec.Values[0].(*ast.CompositeLit); ok {
-					shortPkg := ""
-					typeName := ""
-					if expr, ok := lit.Type.(*ast.SelectorExpr); ok {
-						shortPkg = expr.X.(*ast.Ident).Name
-						typeName = expr.Sel.Name
-					} else if expr, ok := lit.Type.(*ast.Ident); ok {
-						typeName = expr.Name
-					}
-					f.VarMap[name.Name] = &Var{
-						Type:     f.getFullTypeName(shortPkg, typeName, false),
-						Name:     name.Name,
-						NoName:   false,
-						StartPos: f.FSet.Position(name.Pos()).Offset,
-						EndPos:   f.FSet.Position(name.End()).Offset,
-					}
-				}
-			}
+	for i, name := range spec.Names {
+		typeStr := ""
+		if spec.Type != nil {
+			typeStr = f.typeString(spec.Type, false)
+		} else if len(spec.Values) == len(spec.Names) {
+			typeStr = f.valueTypeString(spec.Values[i])
+		}
+		if typeStr == "" {
+			continue
+		}
+		f.VarMap[name.Name] = &Var{
+			Type:      typeStr,
+			Name:      name.Name,
+			NoName:    false,
+			IsPointer: strings.HasPrefix(typeStr, "*"),
+			StartPos:  f.FSet.Position(name.Pos()).Offset,
+			EndPos:    f.endPosition(name).Offset,
+		}
+	}
+}
+
+// valueTypeString 无需类型推导即可确定的值类型：复合字面量 T{} 和 &T{}，其他表达式返回空；
+// s := &T{} 是构造接收者最常见的写法，无法推断时 s.Method() 解析不到方法
+func (f *FileStructVisitor) valueTypeString(value ast.Expr) string {
+	prefix := ""
+	if unaryExpr, ok := value.(*ast.UnaryExpr); ok && unaryExpr.Op == token.AND {
+		prefix = "*"
+		value = unaryExpr.X
+	}
+	if lit, ok := value.(*ast.CompositeLit); ok && lit.Type != nil {
+		return prefix + f.typeString(lit.Type, false)
+	}
+	return ""
+}
+
+// endPosition 节点的结束位置。语法错误的部分 AST 中结束位置可能越过文件末尾，
+// 多个文件共用 FileSet 时会落到并发解析时恰好相邻的其他文件，此时取本文件末尾
+func (f *FileStructVisitor) endPosition(node ast.Node) token.Position {
+	end := node.End()
+	if tokenFile := f.FSet.File(node.Pos()); tokenFile != nil {
+		if eof := token.Pos(tokenFile.Base() + tokenFile.Size()); end > eof {
+			end = eof
 		}
 	}
+	return f.FSet.Position(end)
+}
+
+// lineRange 节点的起止行，语法错误的部分 AST 中结束位置可能缺失，此时按起始行计
+func (f *FileStructVisitor) lineRange(node ast.Node) (int, int) {
+	startLine := f.FSet.Position(node.Pos()).Line
+	endLine := f.endPosition(node).Line
+	if endLine < startLine {
+		endLine = startLine
+	}
+	return startLine, endLine
+}
+
+// sourceLines 第 startLine 到 endLine 行的源码，超出文件范围的部分截断
+func (f *FileStructVisitor) sourceLines(startLine int, endLine int) string {
+	if startLine < 1 {
+		startLine = 1
+	}
+	if endLine > len(f.RawContent) {
+		endLine = len(f.RawContent)
+	}
+	if startLine > endLine {
+		return ""
+	}
+	return strings.Join(f.RawContent[startLine-1:endLine], "\n")
 }
 
 func (f *FileStructVisitor) collectStructAndDeps(n *ast.TypeSpec) {
+	if interfaceType, ok := n.Type.(*ast.InterfaceType); ok {
+		f.collectInterface(n, interfaceType)
+		return
+	}
 	if structType, ok := n.Type.(*ast.StructType); ok {
 		typeName := f.getFullTypeName(n.Name.Name, n.Name.Name, false)
-		startLine := f.FSet.Position(n.Pos()).Line
-		endLine := f.FSet.Position(n.End()).Line
+		startLine, endLine := f.lineRange(n)
 		currentStructInfo := &StructInfo{
-			Repo:      f.RootPkg,
-			Pkg:       f.CurrentPkg,
-			File:      f.RFilePath,
-			Name:      n.Name.Name,
-			TypeName:  typeName,
-			StartLine: startLine,
-			EndLine:   endLine,
-			Content:   strings.Join(f.RawContent[startLine-1:endLine], "\n"),
+			Repo:           f.RootPkg,
+			Pkg:            f.CurrentPkg,
+			PkgName:        f.PkgName,
+			Generated:      f.Generated,
+			File:           f.RFilePath,
+			Name:           n.Name.Name,
+			TypeName:       typeName,
+			StartLine:      startLine,
+			EndLine:        endLine,
+			Content:        f.sourceLines(startLine, endLine),
+			TypeParams:     f.collectTypeParams(n.TypeParams),
+			DepsStructInfo: make(map[string]map[string]StructIndex),
 		}
 		f.StructInfoMap[currentStructInfo.Pkg] = append(f.StructInfoMap[currentStructInfo.Pkg], currentStructInfo)
+		restore := f.withTypeParams(typeParamNames(currentStructInfo.TypeParams)...)
+		defer restore()
 		if structType.Fields != nil {
 			for _, field := range structType.Fields.List {
-				var shortPkg, shortName string
-				expr := field.Type
-				if arrayType, ok := expr.(*ast.ArrayType); ok {
-					shortPkg, shortName = parseStarExprIfNeed(arrayType.Elt, false)
-				} else if mapType, ok := expr.(*ast.MapType); ok {
-					shortPkg, shortName = parseStarExprIfNeed(mapType.Value, false)
-				} else {
-					shortPkg, shortName = parseStarExprIfNeed(expr, false)
+				currentStructInfo.Fields = append(currentStructInfo.Fields, f.newFields(field)...)
+				deps := f.typeDeps(field.Type)
+				// 嵌入字段 T、*T、pkg.T、T[A] 的第一个依赖即嵌入类型本身
+				if len(field.Names) == 0 && len(deps) > 0 {
+					deps[0].Embedded = true
 				}
-				if shortName == "" {
-					continue
-				}
-				completePkg := f.CurrentPkg
-				if shortPkg != "" {
-					if pkg, ok := f.ImportedPkgMap[shortPkg]; ok {
-						completePkg = pkg
+				for _, index := range deps {
+					if _, ok := currentStructInfo.DepsStructInfo[index.Pkg]; !ok {
+						currentStructInfo.DepsStructInfo[index.Pkg] = make(map[string]StructIndex)
 					}
-				}
-				if _, ok := currentStructInfo.DepsStructInfo[completePkg]; !ok {
-					currentStructInfo.DepsStructInfo[completePkg] = make(map[string]StructIndex)
-				}
-				currentStructInfo.DepsStructInfo[completePkg][shortName] = StructIndex{
-					Pkg:  completePkg,
-					Name: shortName,
+					// 同一类型既嵌入又作为普通字段时保留嵌入标记
+					if existing, ok := currentStructInfo.DepsStructInfo[index.Pkg][index.Name]; ok && existing.Embedded {
+						index.Embedded = true
+					}
+					currentStructInfo.DepsStructInfo[index.Pkg][index.Name] = index
 				}
 			}
 		}
 	}
 }
 
-func parseStarExprIfNeed(expr ast.Expr, includeBase bool) (shortPkg string, shortName string) {
-	if starExpr, ok := expr.(*ast.StarExpr); ok {
-		shortPkg, shortName = parseSimpleExpr(starExpr.X, includeBase)
-	} else {
-		shortPkg, shortName = parseSimpleExpr(expr, includeBase)
+// newFields 字段声明展开为每个名字一个字段，嵌入字段以类型名为字段名
+func (f *FileStructVisitor) newFields(field *ast.Field) []*Field {
+	typeStr := f.typeString(field.Type, false)
+	tag := ""
+	if field.Tag != nil {
+		if unquoted, err := strconv.Unquote(field.Tag.Value); err == nil {
+			tag = unquoted
+		}
+	}
+	newField := func(name string, pos token.Pos, embedded bool) *Field {
+		return &Field{
+			Name:     name,
+			Type:     typeStr,
+			Embedded: embedded,
+			Tag:      tag,
+			Tags:     parseStructTag(tag),
+			Doc:      strings.TrimSpace(field.Doc.Text()),
+			Comment:  strings.TrimSpace(field.Comment.Text()),
+			Pos:      f.FSet.Position(pos),
+		}
+	}
+	if len(field.Names) == 0 {
+		_, typeName := SplitTypeName(typeStr)
+		return []*Field{newField(typeName, field.Type.Pos(), true)}
+	}
+	fields := make([]*Field, 0, len(field.Names))
+	for _, name := range field.Names {
+		fields = append(fields, newField(name.Name, name.Pos(), false))
+	}
+	return fields
+}
+
+// collectInterface 采集接口的方法签名和嵌入项
+func (f *FileStructVisitor) collectInterface(n *ast.TypeSpec, interfaceType *ast.InterfaceType) {
+	startLine, endLine := f.lineRange(n)
+	interfaceInfo := &InterfaceInfo{
+		Repo:       f.RootPkg,
+		Pkg:        f.CurrentPkg,
+		PkgName:    f.PkgName,
+		Generated:  f.Generated,
+		File:       f.RFilePath,
+		Name:       n.Name.Name,
+		TypeName:   f.getFullTypeName(n.Name.Name, n.Name.Name, false),
+		StartLine:  startLine,
+		EndLine:    endLine,
+		Content:    f.sourceLines(startLine, endLine),
+		TypeParams: f.collectTypeParams(n.TypeParams),
+		Methods:    make([]*MethodSig, 0),
+		Embeds:     make([]string, 0),
+	}
+	restore := f.withTypeParams(typeParamNames(interfaceInfo.TypeParams)...)
+	defer restore()
+	for _, field := range fieldList(interfaceType.Methods) {
+		if funcType, ok := field.Type.(*ast.FuncType); ok {
+			for _, name := range field.Names {
+				interfaceInfo.Methods = append(interfaceInfo.Methods, &MethodSig{
+					Name:      name.Name,
+					Signature: f.signatureString(funcType),
+				})
+			}
+		} else {
+			interfaceInfo.Embeds = append(interfaceInfo.Embeds, f.typeString(field.Type, false))
+		}
+	}
+	f.InterfaceInfoMap[interfaceInfo.Pkg] = append(f.InterfaceInfoMap[interfaceInfo.Pkg], interfaceInfo)
+}
+
+// typeDeps 类型表达式依赖的命名类型，穿过复合类型、函数签名、匿名结构体/接口和泛型实例化的类型实参，忽略预声明类型和类型参数
+func (f *FileStructVisitor) typeDeps(expr ast.Expr) []StructIndex {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if f.isTypeParam(t.Name) {
+			return nil
+		}
+		if _, ok := predeclaredTypes[t.Name]; ok && t.Obj == nil {
+			return nil
+		}
+		return []StructIndex{f.newStructIndex(f.CurrentPkg, t.Name)}
+	case *ast.SelectorExpr:
+		if _, ok := t.X.(*ast.Ident); !ok {
+			return nil
+		}
+		shortPkg, shortName := parseSimpleExpr(t, true)
+		completePkg := f.CurrentPkg
+		if pkg, ok := f.ImportedPkgMap[shortPkg]; ok {
+			completePkg = pkg
+		}
+		return []StructIndex{f.newStructIndex(completePkg, shortName)}
+	case *ast.ParenExpr:
+		return f.typeDeps(t.X)
+	case *ast.StarExpr:
+		return f.typeDeps(t.X)
+	case *ast.Ellipsis:
+		return f.typeDeps(t.Elt)
+	case *ast.ArrayType:
+		return f.typeDeps(t.Elt)
+	case *ast.MapType:
+		return append(f.typeDeps(t.Key), f.typeDeps(t.Value)...)
+	case *ast.ChanType:
+		return f.typeDeps(t.Value)
+	case *ast.FuncType:
+		return append(f.fieldListDeps(t.Params), f.fieldListDeps(t.Results)...)
+	case *ast.StructType:
+		return f.fieldListDeps(t.Fields)
+	case *ast.InterfaceType:
+		return f.fieldListDeps(t.Methods)
+	case *ast.IndexExpr:
+		return append(f.typeDeps(t.X), f.typeDeps(t.Index)...)
+	case *ast.IndexListExpr:
+		deps := f.typeDeps(t.X)
+		for _, index := range t.Indices {
+			deps = append(deps, f.typeDeps(index)...)
+		}
+		return deps
+	}
+	return nil
+}
+
+func (f *FileStructVisitor) fieldListDeps(list *ast.FieldList) []StructIndex {
+	deps := make([]StructIndex, 0)
+	for _, field := range fieldList(list) {
+		deps = append(deps, f.typeDeps(field.Type)...)
+	}
+	return deps
+}
+
+func (f *FileStructVisitor) newStructIndex(pkg string, name string) StructIndex {
+	return StructIndex{
+		Pkg:      pkg,
+		Name:     name,
+		TypeName: fmt.Sprintf(pkgNameFormat, pkg, name),
 	}
-	return
 }
 
 func parseSimpleExpr(expr ast.Expr, includeBase bool) (shortPkg string, shortName string) {
@@ -201,22 +504,6 @@ func (f *FileStructVisitor) getFullTypeName(shortPkg string, typeName string, re
 }
 
 func isBasicType(name string) bool {
-	switch name {
-	case "bool":
-		return true
-	case "string":
-		return true
-	case "int", "int8", "int16", "int32", "int64":
-		return true
-	case "unit", "unit8", "unit16", "unit32", "unit64":
-		return true
-	case "float32", "float64":
-		return true
-	case "complex64", "complex128":
-		return true
-	case "error":
-		return true
-	default:
-		return false
-	}
+	_, ok := predeclaredTypes[name]
+	return ok
 }
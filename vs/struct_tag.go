@@ -0,0 +1,63 @@
+package vs
+
+import (
+	"strconv"
+	"strings"
+)
+
+// parseStructTag 按 reflect.StructTag 的约定解析 key:"value" 对，格式错误时停止解析并返回已解析部分
+func parseStructTag(tag string) map[string]string {
+	tags := make(map[string]string)
+	for tag != "" {
+		// 1.跳过前导空白
+		i := 0
+		for i < len(tag) && tag[i] == ' ' {
+			i++
+		}
+		tag = tag[i:]
+		if tag == "" {
+			break
+		}
+		// 2.key 为引号和冒号之前的非控制字符
+		i = 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			break
+		}
+		key := tag[:i]
+		tag = tag[i+1:]
+		// 3.value 为带引号的字符串
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			break
+		}
+		value, err := strconv.Unquote(tag[:i+1])
+		if err != nil {
+			break
+		}
+		// 与 reflect.StructTag.Get 一致，重复的 key 取第一个
+		if _, ok := tags[key]; !ok {
+			tags[key] = value
+		}
+		tag = tag[i+1:]
+	}
+	return tags
+}
+
+// TagName 标签值中逗号前的名称部分，如 json:"user_id,omitempty" 的 user_id，标签不存在时返回 false
+func (f *Field) TagName(key string) (string, bool) {
+	value, ok := f.Tags[key]
+	if !ok {
+		return "", false
+	}
+	name, _, _ := strings.Cut(value, ",")
+	return name, true
+}
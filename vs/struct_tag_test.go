@@ -0,0 +1,62 @@
+package vs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseStructTag(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want map[string]string
+	}{
+		{tag: ``, want: map[string]string{}},
+		{tag: `json:"id"`, want: map[string]string{"json": "id"}},
+		{tag: `json:"user_id,omitempty" db:"user_id"`, want: map[string]string{"json": "user_id,omitempty", "db": "user_id"}},
+		// 引号内的逗号、空格和冒号属于值
+		{tag: `validate:"oneof=a,b c" json:"a,omitempty"`, want: map[string]string{"validate": "oneof=a,b c", "json": "a,omitempty"}},
+		{tag: `gorm:"type:varchar(32);default:'a,b'"`, want: map[string]string{"gorm": "type:varchar(32);default:'a,b'"}},
+		// 转义的引号不结束值
+		{tag: `x:"a\"b,c" y:"d"`, want: map[string]string{"x": `a"b,c`, "y": "d"}},
+		{tag: "  json:\"a\"   xml:\"b\"  ", want: map[string]string{"json": "a", "xml": "b"}},
+		{tag: `json:"a" json:"b"`, want: map[string]string{"json": "a"}},
+		// 格式错误时返回已解析部分
+		{tag: `json:"a" bad xml:"b"`, want: map[string]string{"json": "a"}},
+		{tag: `json:"a" xml:b`, want: map[string]string{"json": "a"}},
+		{tag: `json:"unterminated`, want: map[string]string{}},
+		// 与 reflect.StructTag 一致，值之后缺少空格时逗号计入下一个 key
+		{tag: `json:"a",xml:"b"`, want: map[string]string{"json": "a", ",xml": "b"}},
+	}
+	for _, tt := range tests {
+		got := parseStructTag(tt.tag)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseStructTag(%q) = %v, want %v", tt.tag, got, tt.want)
+		}
+		// 解析出的每个 key 与 reflect.StructTag 一致
+		for key, value := range got {
+			if expect, ok := reflect.StructTag(tt.tag).Lookup(key); !ok || expect != value {
+				t.Errorf("parseStructTag(%q)[%q] = %q, reflect.StructTag gives %q %v", tt.tag, key, value, expect, ok)
+			}
+		}
+	}
+}
+
+func TestFieldTagName(t *testing.T) {
+	field := &Field{Tags: parseStructTag(`json:"user_id,omitempty" validate:"oneof=a,b" yaml:",inline"`)}
+	tests := []struct {
+		key    string
+		want   string
+		wantOK bool
+	}{
+		{key: "json", want: "user_id", wantOK: true},
+		{key: "validate", want: "oneof=a", wantOK: true},
+		{key: "yaml", want: "", wantOK: true},
+		{key: "xml", want: "", wantOK: false},
+	}
+	for _, tt := range tests {
+		got, ok := field.TagName(tt.key)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("TagName(%q) = %q %v, want %q %v", tt.key, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package vs
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// unknownType 无法确定的类型
+const unknownType = "unknown"
+
+var predeclaredTypes = map[string]struct{}{
+	"bool": {}, "byte": {}, "complex64": {}, "complex128": {},
+	"error": {}, "float32": {}, "float64": {}, "int": {},
+	"int8": {}, "int16": {}, "int32": {}, "int64": {},
+	"rune": {}, "string": {}, "uint": {}, "uint8": {},
+	"uint16": {}, "uint32": {}, "uint64": {}, "uintptr": {},
+	"any": {}, "comparable": {},
+}
+
+// TypeParam 泛型类型参数及其约束
+type TypeParam struct {
+	Name       string
+	Constraint string
+}
+
+// collectTypeParams 采集类型参数列表，约束按完整包名展开，约束中可引用同一列表的其他类型参数
+func (f *FileStructVisitor) collectTypeParams(list *ast.FieldList) []*TypeParam {
+	if list == nil {
+		return nil
+	}
+	names := make([]string, 0, list.NumFields())
+	for _, field := range list.List {
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+	}
+	restore := f.withTypeParams(names...)
+	defer restore()
+	typeParams := make([]*TypeParam, 0, len(names))
+	for _, field := range list.List {
+		constraint := f.typeString(field.Type, false)
+		for _, name := range field.Names {
+			typeParams = append(typeParams, &TypeParam{
+				Name:       name.Name,
+				Constraint: constraint,
+			})
+		}
+	}
+	return typeParams
+}
+
+// setTypeParams 替换当前作用域的类型参数名，进入新的顶层声明时调用
+func (f *FileStructVisitor) setTypeParams(names ...string) {
+	f.typeParams = make(map[string]struct{}, len(names))
+	for _, name := range names {
+		f.typeParams[name] = struct{}{}
+	}
+}
+
+// withTypeParams 将类型参数名加入当前作用域，返回恢复之前作用域的函数
+func (f *FileStructVisitor) withTypeParams(names ...string) (restore func()) {
+	previous := f.typeParams
+	f.typeParams = make(map[string]struct{}, len(previous)+len(names))
+	for name := range previous {
+		f.typeParams[name] = struct{}{}
+	}
+	for _, name := range names {
+		f.typeParams[name] = struct{}{}
+	}
+	return func() {
+		f.typeParams = previous
+	}
+}
+
+func (f *FileStructVisitor) isTypeParam(name string) bool {
+	_, ok := f.typeParams[name]
+	return ok
+}
+
+// typeString 类型表达式转换为完整包名的规范形式，与 go/types 的类型字符串一致但省略参数名，如
+// map[github.com/x/y.Key][]*ast-callgraph/vs.Var、func(context.Context, ...string) error、<-chan int
+//
+// receiver 为 true 时按接收者处理：类型实参为接收者声明的类型参数名，不计入类型
+func (f *FileStructVisitor) typeString(expr ast.Expr, receiver bool) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if f.isTypeParam(t.Name) {
+			return t.Name
+		}
+		if _, ok := predeclaredTypes[t.Name]; ok && t.Obj == nil {
+			return t.Name
+		}
+		return f.getFullTypeName("", t.Name, receiver)
+	case *ast.SelectorExpr:
+		if _, ok := t.X.(*ast.Ident); !ok {
+			return unknownType
+		}
+		shortPkg, name := parseSimpleExpr(t, true)
+		return f.getFullTypeName(shortPkg, name, receiver)
+	case *ast.ParenExpr:
+		return f.typeString(t.X, receiver)
+	case *ast.StarExpr:
+		return "*" + f.typeString(t.X, receiver)
+	case *ast.Ellipsis:
+		// 变长参数 ...T，数组字面量 [...]T 的长度由 ArrayType 处理
+		return "..." + f.typeString(t.Elt, false)
+	case *ast.ArrayType:
+		if t.Len == nil {
+			return "[]" + f.typeString(t.Elt, false)
+		}
+		return "[" + arrayLenString(t.Len) + "]" + f.typeString(t.Elt, false)
+	case *ast.MapType:
+		return "map[" + f.typeString(t.Key, false) + "]" + f.typeString(t.Value, false)
+	case *ast.ChanType:
+		switch t.Dir {
+		case ast.SEND:
+			return "chan<- " + f.typeString(t.Value, false)
+		case ast.RECV:
+			return "<-chan " + f.typeString(t.Value, false)
+		}
+		// chan (<-chan T) 需要括号区分方向
+		if inner, ok := ast.Unparen(t.Value).(*ast.ChanType); ok && inner.Dir == ast.RECV {
+			return "chan (" + f.typeString(t.Value, false) + ")"
+		}
+		return "chan " + f.typeString(t.Value, false)
+	case *ast.FuncType:
+		return "func" + f.signatureString(t)
+	case *ast.InterfaceType:
+		elems := make([]string, 0)
+		for _, field := range fieldList(t.Methods) {
+			if funcType, ok := field.Type.(*ast.FuncType); ok {
+				for _, name := range field.Names {
+					elems = append(elems, name.Name+f.signatureString(funcType))
+				}
+			} else {
+				// 嵌入接口或类型集合
+				elems = append(elems, f.typeString(field.Type, false))
+			}
+		}
+		return "interface{" + strings.Join(elems, "; ") + "}"
+	case *ast.StructType:
+		fields := make([]string, 0)
+		for _, field := range fieldList(t.Fields) {
+			typeStr := f.typeString(field.Type, false)
+			if len(field.Names) == 0 {
+				fields = append(fields, typeStr)
+			}
+			for _, name := range field.Names {
+				fields = append(fields, name.Name+" "+typeStr)
+			}
+		}
+		return "struct{" + strings.Join(fields, "; ") + "}"
+	case *ast.IndexExpr:
+		if receiver {
+			return f.typeString(t.X, true)
+		}
+		return f.typeString(t.X, false) + "[" + f.typeString(t.Index, false) + "]"
+	case *ast.IndexListExpr:
+		if receiver {
+			return f.typeString(t.X, true)
+		}
+		return f.typeString(t.X, false) + "[" + strings.Join(f.typeStrings(t.Indices), ",") + "]"
+	case *ast.UnaryExpr:
+		// 约束中的近似类型 ~int
+		if t.Op == token.TILDE {
+			return "~" + f.typeString(t.X, false)
+		}
+	case *ast.BinaryExpr:
+		// 约束中的类型并集 ~int | ~string
+		if t.Op == token.OR {
+			return f.typeString(t.X, false) + " | " + f.typeString(t.Y, false)
+		}
+	}
+	return unknownType
+}
+
+func (f *FileStructVisitor) typeStrings(exprs []ast.Expr) []string {
+	result := make([]string, 0, len(exprs))
+	for _, expr := range exprs {
+		result = append(result, f.typeString(expr, false))
+	}
+	return result
+}
+
+// signatureString 函数签名 (params) results，省略参数名
+func (f *FileStructVisitor) signatureString(funcType *ast.FuncType) string {
+	return FormatSignature(f.fieldTypes(funcType.Params), f.fieldTypes(funcType.Results))
+}
+
+// FormatSignature 由参数和返回值类型构造签名，单个返回值不加括号，与参数名无关以便比较
+func FormatSignature(params []string, results []string) string {
+	signature := "(" + strings.Join(params, ", ") + ")"
+	switch len(results) {
+	case 0:
+		return signature
+	case 1:
+		return signature + " " + results[0]
+	default:
+		return signature + " (" + strings.Join(results, ", ") + ")"
+	}
+}
+
+// fieldTypes 字段列表展开为每个名字一项的类型列表
+func (f *FileStructVisitor) fieldTypes(list *ast.FieldList) []string {
+	result := make([]string, 0)
+	for _, field := range fieldList(list) {
+		typeStr := f.typeString(field.Type, false)
+		for i := 0; i < max(len(field.Names), 1); i++ {
+			result = append(result, typeStr)
+		}
+	}
+	return result
+}
+
+func fieldList(list *ast.FieldList) []*ast.Field {
+	if list == nil {
+		return nil
+	}
+	return list.List
+}
+
+// arrayLenString 数组长度，字面量取值，常量表达式原样输出
+func arrayLenString(expr ast.Expr) string {
+	if _, ok := expr.(*ast.Ellipsis); ok {
+		return "..."
+	}
+	return types.ExprString(expr)
+}
+
+// receiverTypeParams 泛型接收者 (c *Cache[K, V]) 中声明的类型参数名
+func receiverTypeParams(recv *ast.FieldList) []string {
+	if recv == nil || len(recv.List) == 0 {
+		return nil
+	}
+	expr := recv.List[0].Type
+	if starExpr, ok := expr.(*ast.StarExpr); ok {
+		expr = starExpr.X
+	}
+	var indices []ast.Expr
+	switch t := expr.(type) {
+	case *ast.IndexExpr:
+		indices = []ast.Expr{t.Index}
+	case *ast.IndexListExpr:
+		indices = t.Indices
+	}
+	names := make([]string, 0, len(indices))
+	for _, index := range indices {
+		if ident, ok := index.(*ast.Ident); ok && ident.Name != "_" {
+			names = append(names, ident.Name)
+		}
+	}
+	return names
+}
+
+// typeParamNames 类型参数名列表
+func typeParamNames(typeParams []*TypeParam) []string {
+	names := make([]string, 0, len(typeParams))
+	for _, typeParam := range typeParams {
+		names = append(names, typeParam.Name)
+	}
+	return names
+}
@@ -0,0 +1,130 @@
+package vs
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+const typeExprImports = `package p
+
+import (
+	"context"
+	"fmt"
+	y "gopkg.in/yaml.v3"
+	"github.com/google/uuid/v2"
+)
+`
+
+func TestTypeString(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{expr: "int", want: "int"},
+		{expr: "error", want: "error"},
+		{expr: "any", want: "any"},
+		{expr: "T", want: "example.com/m/p.T"},
+		{expr: "*context.Context", want: "*context.Context"},
+		{expr: "y.Node", want: "gopkg.in/yaml.v3.Node"},
+		{expr: "uuid.UUID", want: "github.com/google/uuid/v2.UUID"},
+		{expr: "(T)", want: "example.com/m/p.T"},
+		{expr: "[]byte", want: "[]byte"},
+		{expr: "[4]*T", want: "[4]*example.com/m/p.T"},
+		{expr: "[N + 1]int", want: "[N + 1]int"},
+		{expr: "map[string][]*T", want: "map[string][]*example.com/m/p.T"},
+		{expr: "chan int", want: "chan int"},
+		{expr: "chan<- int", want: "chan<- int"},
+		{expr: "<-chan int", want: "<-chan int"},
+		{expr: "chan (<-chan int)", want: "chan (<-chan int)"},
+		// 函数类型省略参数名，变长参数保留 ...
+		{expr: "func()", want: "func()"},
+		{expr: "func(ctx context.Context, args ...string) error", want: "func(context.Context, ...string) error"},
+		{expr: "func(a, b int) (T, error)", want: "func(int, int) (example.com/m/p.T, error)"},
+		{expr: "func(func(int) bool) func() T", want: "func(func(int) bool) func() example.com/m/p.T"},
+		// 泛型实例化，类型参数名不带包名
+		{expr: "List[K]", want: "example.com/m/p.List[K]"},
+		{expr: "Map[K, V]", want: "example.com/m/p.Map[K,V]"},
+		{expr: "Map[string, *y.Node]", want: "example.com/m/p.Map[string,*gopkg.in/yaml.v3.Node]"},
+		{expr: "func(List[V]) (K, bool)", want: "func(example.com/m/p.List[V]) (K, bool)"},
+		{expr: "interface{ M(int) error; fmt.Stringer }", want: "interface{M(int) error; fmt.Stringer}"},
+		{expr: "struct{ A, B int; T }", want: "struct{A int; B int; example.com/m/p.T}"},
+	}
+	var src strings.Builder
+	src.WriteString(typeExprImports)
+	for _, tt := range tests {
+		src.WriteString("func _[K comparable, V any](_ " + tt.expr + ") {}\n")
+	}
+	visitor, astFile := walkSource(t, src.String())
+	decls := funcDecls(astFile)
+	for i, tt := range tests {
+		restore := visitor.withTypeParams("K", "V")
+		got := visitor.typeString(decls[i].Type.Params.List[0].Type, false)
+		restore()
+		if got != tt.want {
+			t.Errorf("typeString(%s) = %q, want %q", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestTypeParamConstraint(t *testing.T) {
+	tests := []struct {
+		typeParams string
+		want       []string
+	}{
+		{typeParams: "[T any]", want: []string{"T any"}},
+		{typeParams: "[K comparable, V any]", want: []string{"K comparable", "V any"}},
+		{typeParams: "[N ~int | ~int64 | float64]", want: []string{"N ~int | ~int64 | float64"}},
+		{typeParams: "[S ~[]E, E fmt.Stringer]", want: []string{"S ~[]E", "E fmt.Stringer"}},
+		{typeParams: "[P interface{ *T; Set(string) }]", want: []string{"P interface{*example.com/m/p.T; Set(string)}"}},
+	}
+	var src strings.Builder
+	src.WriteString(typeExprImports)
+	for _, tt := range tests {
+		src.WriteString("func _" + tt.typeParams + "() {}\n")
+	}
+	visitor, astFile := walkSource(t, src.String())
+	decls := funcDecls(astFile)
+	for i, tt := range tests {
+		got := make([]string, 0)
+		for _, typeParam := range visitor.collectTypeParams(decls[i].Type.TypeParams) {
+			got = append(got, typeParam.Name+" "+typeParam.Constraint)
+		}
+		if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+			t.Errorf("collectTypeParams(%s) = %q, want %q", tt.typeParams, got, tt.want)
+		}
+	}
+}
+
+func TestTypeStringBadExpr(t *testing.T) {
+	visitor := NewFileStructVisitor("example.com/m", "example.com/m/p", "/m/p/p.go", "p/p.go", token.NewFileSet(), nil)
+	for _, expr := range []ast.Expr{&ast.BadExpr{}, &ast.SelectorExpr{X: &ast.CallExpr{}, Sel: ast.NewIdent("T")}} {
+		if got := visitor.typeString(expr, false); got != unknownType {
+			t.Errorf("typeString(%T) = %q, want %q", expr, got, unknownType)
+		}
+	}
+}
+
+func walkSource(t *testing.T, src string) (*FileStructVisitor, *ast.File) {
+	t.Helper()
+	fset := token.NewFileSet()
+	astFile, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	visitor := NewFileStructVisitor("example.com/m", "example.com/m/p", "/m/p/p.go", "p/p.go", fset, []byte(src))
+	ast.Walk(visitor, astFile)
+	return visitor, astFile
+}
+
+func funcDecls(astFile *ast.File) []*ast.FuncDecl {
+	decls := make([]*ast.FuncDecl, 0)
+	for _, decl := range astFile.Decls {
+		if funcDecl, ok := decl.(*ast.FuncDecl); ok {
+			decls = append(decls, funcDecl)
+		}
+	}
+	return decls
+}
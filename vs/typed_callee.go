@@ -0,0 +1,180 @@
+package vs
+
+import (
+	"go/ast"
+	"go/types"
+)
+
+// handleTypedCallExpr 基于类型信息解析调用，函数值参数由 handleTypedReference 处理
+func (f *FileFuncVisitor) handleTypedCallExpr(expr *ast.CallExpr, goFunc *GoFunc) {
+	if fn := f.typedFunc(expr.Fun); fn != nil {
+		f.appendTypedCallee(fn, expr.Fun, goFunc, false)
+	}
+}
+
+// handleTypedReference 记录非调用位置引用的函数或方法值，标记为 Reference。
+// 回调注册、函数表等引用的函数可能在模块外被调用，不记录时可达性分析会把它们报告为死代码
+func (f *FileFuncVisitor) handleTypedReference(ident *ast.Ident, goFunc *GoFunc) {
+	if fn, ok := f.TypesInfo.Uses[ident].(*types.Func); ok {
+		f.appendTypedCallee(fn, ident, goFunc, true)
+	}
+}
+
+// calleeIdent 调用表达式中被调函数对应的标识符
+func calleeIdent(fun ast.Expr) *ast.Ident {
+	fun = ast.Unparen(fun)
+	switch e := fun.(type) {
+	case *ast.IndexExpr:
+		fun = ast.Unparen(e.X)
+	case *ast.IndexListExpr:
+		fun = ast.Unparen(e.X)
+	}
+	switch e := fun.(type) {
+	case *ast.Ident:
+		return e
+	case *ast.SelectorExpr:
+		return e.Sel
+	}
+	return nil
+}
+
+// typedFunc 表达式引用的具体函数或方法，函数变量、内置函数和类型转换返回nil
+func (f *FileFuncVisitor) typedFunc(expr ast.Expr) *types.Func {
+	expr = ast.Unparen(expr)
+	// 泛型函数显式实例化 F[T]()
+	switch e := expr.(type) {
+	case *ast.IndexExpr:
+		expr = ast.Unparen(e.X)
+	case *ast.IndexListExpr:
+		expr = ast.Unparen(e.X)
+	}
+	var obj types.Object
+	switch e := expr.(type) {
+	case *ast.Ident:
+		obj = f.TypesInfo.Uses[e]
+	case *ast.SelectorExpr:
+		if sel, ok := f.TypesInfo.Selections[e]; ok {
+			obj = sel.Obj()
+		} else {
+			obj = f.TypesInfo.Uses[e.Sel]
+		}
+	}
+	fn, _ := obj.(*types.Func)
+	return fn
+}
+
+func (f *FileFuncVisitor) appendTypedCallee(fn *types.Func, expr ast.Expr, goFunc *GoFunc, reference bool) {
+	// error.Error 等预声明方法没有所属包
+	if fn.Pkg() == nil {
+		return
+	}
+	typeArgs := f.typeArgs(fn, expr)
+	fn = fn.Origin()
+	info := &CalleeInfo{
+		Pkg:       fn.Pkg().Path(),
+		File:      goFunc.RFile,
+		Name:      fn.Name(),
+		Begin:     f.FSet.Position(expr.Pos()),
+		End:       f.endPosition(expr),
+		Reference: reference,
+		TypeArgs:  typeArgs,
+	}
+	if sig, ok := fn.Type().(*types.Signature); ok && sig.Recv() != nil {
+		recvType := TypeName(sig.Recv().Type())
+		info.Receiver = &recvType
+	}
+	goFunc.CalleeInfos = append(goFunc.CalleeInfos, info)
+	f.appendDynamicCallees(fn, info, goFunc)
+}
+
+// typeArgs 泛型函数实例化(含类型推断)的类型实参，泛型类型的方法取接收者的类型实参
+func (f *FileFuncVisitor) typeArgs(fn *types.Func, expr ast.Expr) []string {
+	var list *types.TypeList
+	if ident := calleeIdent(expr); ident != nil {
+		if instance, ok := f.TypesInfo.Instances[ident]; ok {
+			list = instance.TypeArgs
+		}
+	}
+	if list == nil {
+		if sig, ok := fn.Type().(*types.Signature); ok && sig.Recv() != nil {
+			recvType := sig.Recv().Type()
+			if ptr, ok := recvType.(*types.Pointer); ok {
+				recvType = ptr.Elem()
+			}
+			if named, ok := types.Unalias(recvType).(*types.Named); ok {
+				list = named.TypeArgs()
+			}
+		}
+	}
+	if list.Len() == 0 {
+		return nil
+	}
+	typeArgs := make([]string, 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		typeArgs = append(typeArgs, types.TypeString(list.At(i), qualifyByPath))
+	}
+	return typeArgs
+}
+
+// qualifyByPath 类型字符串中的包名使用完整包路径
+func qualifyByPath(pkg *types.Package) string {
+	return pkg.Path()
+}
+
+// appendDynamicCallees 接口方法调用展开为模块内全部实现类型的对应方法(CHA)，标记为动态调用
+func (f *FileFuncVisitor) appendDynamicCallees(fn *types.Func, staticInfo *CalleeInfo, goFunc *GoFunc) {
+	if f.Implementers == nil {
+		return
+	}
+	sig, ok := fn.Type().(*types.Signature)
+	if !ok || sig.Recv() == nil {
+		return
+	}
+	iface, ok := sig.Recv().Type().Underlying().(*types.Interface)
+	if !ok {
+		return
+	}
+	seen := make(map[*types.Func]struct{})
+	for _, named := range f.Implementers(iface) {
+		// 值接收者和指针接收者的方法都在指针类型的方法集中，含嵌入提升的方法
+		obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(named), false, fn.Pkg(), fn.Name())
+		method, ok := obj.(*types.Func)
+		if !ok || method.Pkg() == nil {
+			continue
+		}
+		if _, ok := seen[method]; ok {
+			continue
+		}
+		seen[method] = struct{}{}
+		recvType := TypeName(method.Type().(*types.Signature).Recv().Type())
+		goFunc.CalleeInfos = append(goFunc.CalleeInfos, &CalleeInfo{
+			Pkg:       method.Pkg().Path(),
+			File:      staticInfo.File,
+			Name:      method.Name(),
+			Begin:     staticInfo.Begin,
+			End:       staticInfo.End,
+			Receiver:  &recvType,
+			Dynamic:   true,
+			Reference: staticInfo.Reference,
+		})
+	}
+}
+
+// TypeName 命名类型的完整名称 pkg/path.Type，指针保留 * 前缀，泛型去掉类型实参
+func TypeName(t types.Type) string {
+	prefix := ""
+	if ptr, ok := t.(*types.Pointer); ok {
+		prefix = "*"
+		t = ptr.Elem()
+	}
+	switch named := types.Unalias(t).(type) {
+	case *types.Named:
+		obj := named.Origin().Obj()
+		if obj.Pkg() == nil {
+			return prefix + obj.Name()
+		}
+		return prefix + obj.Pkg().Path() + "." + obj.Name()
+	default:
+		return prefix + types.TypeString(t, nil)
+	}
+}